@@ -1,49 +1,141 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"httpserver/internal/config"
 	"httpserver/internal/controller"
-	"httpserver/internal/storage/activeuserstorage"
-	"httpserver/internal/storage/tokenstorage"
-	"httpserver/internal/storage/userstorage"
+	"httpserver/internal/hub"
+	"httpserver/internal/notifier"
+	"httpserver/internal/policy"
+	"httpserver/internal/pubsub"
+	"httpserver/internal/ratelimit"
+	"httpserver/internal/server"
+	"httpserver/internal/tracing"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
 
+// shutdownTimeout is how long requests in flight get to finish once the server is asked to stop.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	router := chi.NewRouter()
-	userStorage := userstorage.NewUserStorage()
-	tokenStorage := tokenstorage.NewTokenStorage()
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
 	logger, err := zap.NewProduction()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer logger.Sync()
-	sugar := logger.Sugar()
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-	router.Post("/user", func(w http.ResponseWriter, r *http.Request) {
-		controller.UserHandler(w, r, userStorage, sugar)
-	})
-
-	router.Post("/user/login", func(w http.ResponseWriter, r *http.Request) {
-		controller.UserLoginHandler(w, r, userStorage, sugar, tokenStorage)
-	})
-
-	router.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
-		controller.Ws(w, r, tokenStorage, activeUsersStorage, sugar)
-	})
-	router.Get("/user/active/list", func(w http.ResponseWriter, r *http.Request) {
-		controller.UserGetActiveList(w, activeUsersStorage)
-	})
-
-	http.Handle("/", router)
-
-	log.Fatal(http.ListenAndServe(config.GetPort(), nil))
+	zap.ReplaceGlobals(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.GetTracingExporter(), config.GetOtlpEndpoint())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	userPolicy, err := policy.FromConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userNotifier, err := notifier.New(config.GetNotifier(), config.GetNotifierFile(), config.GetNotifierLogTokens())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	overflowPolicy, err := hub.ParseOverflowPolicy(config.GetSendBufferPolicy())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	wsRates, err := ratelimit.ParseRates(config.GetWsRateLimits())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	wsCompression := controller.WsCompression{
+		Enabled:   config.GetWsCompression(),
+		Level:     config.GetWsCompressionLevel(),
+		Threshold: config.GetWsCompressionThreshold(),
+	}
+	if err := wsCompression.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	relay, err := pubsub.New(context.Background(), config.GetRedisUrl())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer relay.Close()
+
+	options := []server.Option{
+		server.WithLogger(logger),
+		server.WithPolicy(userPolicy),
+		server.WithNotifier(userNotifier),
+		server.WithPasswordResetTTL(config.GetPasswordResetTokenTTL()),
+		server.WithPollTimeout(config.GetPollTimeout()),
+		server.WithWsTimeouts(controller.WsTimeouts{
+			PingInterval: config.GetWsPingInterval(),
+			PongWait:     config.GetWsPongWait(),
+			IdleTimeout:  config.GetWsIdleTimeout(),
+			SessionTTL:   config.GetWsSessionTTL(),
+			AuthTimeout:  config.GetWsAuthTimeout(),
+		}),
+		server.WithWsLimits(controller.WsLimits{
+			MaxMessageSize: config.GetWsMaxMessageSize(),
+			Rates:          wsRates,
+		}),
+		server.WithWsCompression(wsCompression),
+		server.WithAllowedOrigins(config.GetAllowedOrigins()...),
+		server.WithSendBuffer(config.GetSendBufferSize(), overflowPolicy),
+		server.WithPubSub(relay),
+		server.WithPresenceInterval(config.GetPresenceInterval()),
+	}
+	if userName, password := config.GetAdminUserName(), config.GetAdminPassword(); userName != "" && password != "" {
+		options = append(options, server.WithAdmin(userName, password))
+	}
+	if deprecation := config.GetLegacyRoutesDeprecation(); deprecation != "" {
+		deprecationTime, err := time.Parse(time.RFC3339, deprecation)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, server.WithLegacyRoutesDeprecation(deprecationTime))
+	}
+	if sunset := config.GetLegacyRoutesSunset(); sunset != "" {
+		sunsetTime, err := time.Parse(time.RFC3339, sunset)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, server.WithLegacyRoutesSunset(sunsetTime))
+	}
+
+	srv := server.New(options...)
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{Addr: config.GetPort(), Handler: srv}
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-stopped:
+		logger.Fatal("server stopped", zap.Error(err))
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("server did not shut down cleanly", zap.Error(err))
+	}
 }
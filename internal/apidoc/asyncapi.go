@@ -0,0 +1,84 @@
+package apidoc
+
+import "strings"
+
+type AsyncAPI struct {
+	AsyncAPI   string                    `json:"asyncapi"`
+	Info       Info                      `json:"info"`
+	Channels   map[string]*Channel       `json:"channels"`
+	Operations map[string]AsyncOperation `json:"operations"`
+	Components AsyncComponents           `json:"components"`
+}
+
+type Channel struct {
+	Address     string               `json:"address"`
+	Description string               `json:"description,omitempty"`
+	Messages    map[string]*Schema   `json:"messages"`
+	Bindings    map[string]WsBinding `json:"bindings,omitempty"`
+}
+
+type WsBinding struct {
+	Method  string  `json:"method,omitempty"`
+	Query   *Schema `json:"query,omitempty"`
+	Headers *Schema `json:"headers,omitempty"`
+}
+
+type AsyncOperation struct {
+	Action   string    `json:"action"`
+	Summary  string    `json:"summary,omitempty"`
+	Channel  *Schema   `json:"channel"`
+	Messages []*Schema `json:"messages"`
+}
+
+type AsyncComponents struct {
+	Messages map[string]Message `json:"messages"`
+	Schemas  schemaRegistry     `json:"schemas,omitempty"`
+}
+
+type Message struct {
+	Name    string  `json:"name"`
+	Summary string  `json:"summary,omitempty"`
+	Payload *Schema `json:"payload"`
+}
+
+const (
+	ActionSend    = "send"
+	ActionReceive = "receive"
+)
+
+func NewAsyncAPI(title string, version string) *AsyncAPI {
+	return &AsyncAPI{
+		AsyncAPI:   "3.0.0",
+		Info:       Info{Title: title, Version: version},
+		Channels:   map[string]*Channel{},
+		Operations: map[string]AsyncOperation{},
+		Components: AsyncComponents{
+			Messages: map[string]Message{},
+			Schemas:  schemaRegistry{},
+		},
+	}
+}
+
+func (doc *AsyncAPI) AddChannel(name string, channel *Channel) {
+	if channel.Messages == nil {
+		channel.Messages = map[string]*Schema{}
+	}
+	doc.Channels[name] = channel
+}
+
+func (doc *AsyncAPI) AddMessage(channelName string, message Message, action string, summary string) {
+	doc.Components.Messages[message.Name] = message
+	doc.Channels[channelName].Messages[message.Name] = &Schema{Ref: "#/components/messages/" + message.Name}
+
+	operationId := action + strings.ToUpper(message.Name[:1]) + message.Name[1:]
+	doc.Operations[operationId] = AsyncOperation{
+		Action:   action,
+		Summary:  summary,
+		Channel:  &Schema{Ref: "#/channels/" + channelName},
+		Messages: []*Schema{{Ref: "#/channels/" + channelName + "/messages/" + message.Name}},
+	}
+}
+
+func (doc *AsyncAPI) SchemaRef(v interface{}) *Schema {
+	return doc.Components.Schemas.ref(v)
+}
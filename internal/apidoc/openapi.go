@@ -0,0 +1,129 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeText = "text/plain"
+)
+
+type OpenAPI struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         schemaRegistry            `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+func NewOpenAPI(title string, version string) *OpenAPI {
+	return &OpenAPI{
+		OpenAPI: "3.1.0",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         schemaRegistry{},
+			SecuritySchemes: map[string]SecurityScheme{},
+		},
+	}
+}
+
+func (doc *OpenAPI) AddOperation(method string, path string, operation *Operation) {
+	if doc.Paths[path] == nil {
+		doc.Paths[path] = PathItem{}
+	}
+	doc.Paths[path][strings.ToLower(method)] = operation
+}
+
+func (doc *OpenAPI) HasOperation(method string, path string) bool {
+	_, ok := doc.Paths[path][strings.ToLower(method)]
+	return ok
+}
+
+func (doc *OpenAPI) SchemaRef(v interface{}) *Schema {
+	return doc.Components.Schemas.ref(v)
+}
+
+func JSONContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{ContentTypeJSON: {Schema: schema}}
+}
+
+func TextContent() map[string]MediaType {
+	return map[string]MediaType{ContentTypeText: {Schema: &Schema{Type: "string"}}}
+}
+
+func Handler(doc interface{}) http.Handler {
+	body, err := json.Marshal(doc)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,135 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"httpserver/internal/validation"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+type schemaRegistry map[string]*Schema
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (registry schemaRegistry) ref(v interface{}) *Schema {
+	return registry.schemaFor(reflect.TypeOf(v))
+}
+
+func (registry schemaRegistry) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == rawMessageType {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: registry.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: registry.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return registry.structSchema(t)
+		}
+		if _, ok := registry[t.Name()]; !ok {
+			registry[t.Name()] = &Schema{}
+			*registry[t.Name()] = *registry.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	return &Schema{}
+}
+
+func (registry schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := registry.schemaFor(field.Type)
+		applyRules(property, validation.ParseTag(field.Tag.Get("validate")))
+		schema.Properties[name] = property
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func applyRules(schema *Schema, rules []validation.Rule) {
+	for _, rule := range rules {
+		limit, err := strconv.Atoi(rule.Param)
+		switch {
+		case rule.Name == validation.RuleMin && err == nil:
+			if schema.Type == "string" {
+				schema.MinLength = &limit
+			} else if schema.Type == "integer" || schema.Type == "number" {
+				schema.Minimum = &limit
+			}
+		case rule.Name == validation.RuleMax && err == nil:
+			if schema.Type == "string" {
+				schema.MaxLength = &limit
+			} else if schema.Type == "integer" || schema.Type == "number" {
+				schema.Maximum = &limit
+			}
+		case rule.Name == validation.RuleOneOf:
+			schema.Enum = strings.Split(rule.Param, "|")
+		case rule.Name == validation.RuleCharset:
+			schema.Description = "Allowed characters: " + rule.Param
+		}
+	}
+}
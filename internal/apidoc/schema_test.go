@@ -0,0 +1,101 @@
+package apidoc_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/apidoc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type profile struct {
+	Name      string            `json:"name"`
+	Bio       string            `json:"bio,omitempty"`
+	Age       int               `json:"age"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Address   *address          `json:"address"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Secret    string            `json:"-"`
+	internal  string
+}
+
+func TestOpenAPI_SchemaRef(t *testing.T) {
+	doc := apidoc.NewOpenAPI("test", "1.0.0")
+
+	ref := doc.SchemaRef(profile{})
+	assert.Equal(t, "#/components/schemas/profile", ref.Ref)
+
+	schema := doc.Components.Schemas["profile"]
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"name", "age", "tags", "address", "createdAt"}, schema.Required)
+	assert.Equal(t, "integer", schema.Properties["age"].Type)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.Equal(t, "string", schema.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "#/components/schemas/address", schema.Properties["address"].Ref)
+	assert.Equal(t, "date-time", schema.Properties["createdAt"].Format)
+	assert.NotContains(t, schema.Properties, "Secret")
+	assert.NotContains(t, schema.Properties, "internal")
+	assert.Contains(t, doc.Components.Schemas, "address")
+}
+
+func TestOpenAPI_SchemaRefRawMessage(t *testing.T) {
+	doc := apidoc.NewOpenAPI("test", "1.0.0")
+
+	raw := doc.SchemaRef(struct {
+		Data json.RawMessage `json:"data"`
+	}{})
+	bytes := doc.SchemaRef(struct {
+		Data []byte `json:"data"`
+	}{})
+
+	assert.Equal(t, &apidoc.Schema{}, raw.Properties["data"])
+	assert.Equal(t, "byte", bytes.Properties["data"].Format)
+}
+
+func TestOpenAPI_AddOperation(t *testing.T) {
+	doc := apidoc.NewOpenAPI("test", "1.0.0")
+
+	doc.AddOperation("POST", "/user", &apidoc.Operation{OperationID: "createUser"})
+
+	assert.True(t, doc.HasOperation("post", "/user"))
+	assert.False(t, doc.HasOperation("get", "/user"))
+
+	body, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"post":{"operationId":"createUser"`)
+}
+
+func TestAsyncAPI_AddMessage(t *testing.T) {
+	doc := apidoc.NewAsyncAPI("test", "1.0.0")
+	doc.AddChannel("ws", &apidoc.Channel{Address: "/ws"})
+
+	doc.AddMessage("ws", apidoc.Message{Name: "echo", Payload: &apidoc.Schema{Type: "object"}}, apidoc.ActionSend, "")
+
+	assert.Contains(t, doc.Components.Messages, "echo")
+	assert.Equal(t, "#/components/messages/echo", doc.Channels["ws"].Messages["echo"].Ref)
+	assert.Equal(t, apidoc.ActionSend, doc.Operations["sendEcho"].Action)
+}
+
+type credentials struct {
+	UserName string `json:"userName" validate:"required,min=4,max=32,charset=alnum"`
+	Role     string `json:"role,omitempty" validate:"oneof=admin|member"`
+	Age      int    `json:"age" validate:"min=18"`
+}
+
+func TestOpenAPI_SchemaRefAppliesValidationRules(t *testing.T) {
+	doc := apidoc.NewOpenAPI("test", "1.0.0")
+
+	doc.SchemaRef(credentials{})
+
+	schema := doc.Components.Schemas["credentials"]
+	assert.Equal(t, 4, *schema.Properties["userName"].MinLength)
+	assert.Equal(t, 32, *schema.Properties["userName"].MaxLength)
+	assert.Equal(t, []string{"admin", "member"}, schema.Properties["role"].Enum)
+	assert.Equal(t, 18, *schema.Properties["age"].Minimum)
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"httpserver/internal/logging"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/userstorage"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const userKey contextKey = iota
+
+func NewContext(ctx context.Context, user *storage.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+func UserFromContext(ctx context.Context) (*storage.User, bool) {
+	user, ok := ctx.Value(userKey).(*storage.User)
+	return user, ok
+}
+
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// Middleware looks the user of the session up on every request, so the handlers get their own copy and a ban applies
+// to the open sessions at once.
+func Middleware(sessionStorage sessionstorage.SessionStorageInterface, userStorage userstorage.UserStorageInterface) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var user *storage.User
+			userID, err := sessionStorage.Get(r.Context(), BearerToken(r))
+			if err == nil {
+				user, err = userStorage.GetByID(r.Context(), userID)
+			}
+			if err == nil && user.Banned {
+				err = errors.New("user is banned")
+			}
+			if err != nil {
+				logging.FromContext(r.Context()).Error(err.Error())
+				Unauthorized(w)
+				return
+			}
+
+			logging.SetUser(r.Context(), user.UserName)
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), user)))
+		})
+	}
+}
+
+func Unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(responses.ErrorResponse{Error: "unauthorized"})
+}
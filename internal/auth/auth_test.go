@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"context"
+	"httpserver/internal/auth"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/userstorage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer abc123": "abc123",
+		"bearer abc123": "abc123",
+		"Basic abc123":  "",
+		"abc123":        "",
+		"":              "",
+	}
+
+	for header, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+
+		assert.Equal(t, expected, auth.BearerToken(req), header)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	userStorage := userstorage.NewUserStorage()
+	id, _ := userStorage.Add(context.Background(), "JohnDoe", "password123")
+	sessionStorage.Add(context.Background(), "abc123", id, time.Now().Add(time.Hour))
+
+	var contextUser *storage.User
+	handler := auth.Middleware(sessionStorage, userStorage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextUser, _ = auth.UserFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	user, _ := userStorage.GetByID(context.Background(), id)
+	assert.Equal(t, user, contextUser)
+}
+
+func TestMiddleware_InvalidToken(t *testing.T) {
+	handler := auth.Middleware(sessionstorage.NewSessionStorage(), userstorage.NewUserStorage())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error":"unauthorized"}`, rr.Body.String())
+}
+
+func TestMiddleware_BannedUser(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	userStorage := userstorage.NewUserStorage()
+	id, _ := userStorage.Add(context.Background(), "JohnDoe", "password123")
+	userStorage.Update(context.Background(), &storage.User{UserName: "JohnDoe", Uuid: id, Banned: true})
+	sessionStorage.Add(context.Background(), "abc123", id, time.Now().Add(time.Hour))
+
+	handler := auth.Middleware(sessionStorage, userStorage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestMiddleware_DeletedUser(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "abc123", "unknown", time.Now().Add(time.Hour))
+
+	handler := auth.Middleware(sessionStorage, userstorage.NewUserStorage())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/json"
+	"httpserver/internal/logging"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage"
+	"net/http"
+)
+
+type Permission string
+
+const (
+	RoleAdmin = "admin"
+
+	PermissionUsersRead          Permission = "users:read"
+	PermissionUsersBan           Permission = "users:ban"
+	PermissionSessionsRead       Permission = "sessions:read"
+	PermissionSessionsDisconnect Permission = "sessions:disconnect"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersBan,
+		PermissionSessionsRead,
+		PermissionSessionsDisconnect,
+	},
+}
+
+func HasRole(user *storage.User, role string) bool {
+	for _, userRole := range user.Roles {
+		if userRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func HasPermission(user *storage.User, permission Permission) bool {
+	for _, role := range user.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Require must be mounted after Middleware, it rejects authenticated users lacking the permission.
+func Require(permission Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			if !ok {
+				Unauthorized(w)
+				return
+			}
+			if !HasPermission(user, permission) {
+				logging.FromContext(r.Context()).Errorw("permission denied", "permission", permission)
+				Forbidden(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func Forbidden(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(responses.ErrorResponse{Error: "forbidden"})
+}
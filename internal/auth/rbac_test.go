@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"httpserver/internal/auth"
+	"httpserver/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	admin := &storage.User{UserName: "root", Roles: []string{auth.RoleAdmin}}
+	user := &storage.User{UserName: "JohnDoe"}
+
+	assert.True(t, auth.HasPermission(admin, auth.PermissionUsersBan))
+	assert.False(t, auth.HasPermission(user, auth.PermissionUsersBan))
+	assert.False(t, auth.HasPermission(&storage.User{Roles: []string{"unknown"}}, auth.PermissionUsersRead))
+}
+
+func TestRequire(t *testing.T) {
+	handler := auth.Require(auth.PermissionSessionsRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		user     *storage.User
+		expected int
+	}{
+		{name: "anonymous", expected: http.StatusUnauthorized},
+		{name: "user", user: &storage.User{UserName: "JohnDoe"}, expected: http.StatusForbidden},
+		{name: "admin", user: &storage.User{UserName: "root", Roles: []string{auth.RoleAdmin}}, expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.user != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), test.user))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expected, rr.Code)
+		})
+	}
+}
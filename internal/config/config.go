@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 func GetPort() string {
 	port := os.Getenv("PORT")
@@ -19,3 +24,212 @@ func GetBaseUrl() string {
 
 	return baseUrl
 }
+
+func GetTracingExporter() string {
+	exporter := os.Getenv("TRACING_EXPORTER")
+	if exporter == "" {
+		exporter = "none"
+	}
+
+	return exporter
+}
+
+func GetOtlpEndpoint() string {
+	endpoint := os.Getenv("OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "localhost:4318"
+	}
+
+	return endpoint
+}
+
+func GetLegacyRoutesDeprecation() string {
+	return os.Getenv("LEGACY_ROUTES_DEPRECATION")
+}
+
+func GetLegacyRoutesSunset() string {
+	return os.Getenv("LEGACY_ROUTES_SUNSET")
+}
+
+// GetAllowedOrigins returns the origins allowed to call the API from a browser, none unless ALLOWED_ORIGINS is set.
+func GetAllowedOrigins() []string {
+	origins := os.Getenv("ALLOWED_ORIGINS")
+	if origins == "" {
+		return nil
+	}
+
+	return strings.Split(origins, ",")
+}
+
+func GetUserNameMinLength() int {
+	return getInt("USERNAME_MIN_LENGTH", 4)
+}
+
+func GetUserNameMaxLength() int {
+	return getInt("USERNAME_MAX_LENGTH", 32)
+}
+
+func GetUserNameAllowedSymbols() string {
+	symbols, ok := os.LookupEnv("USERNAME_ALLOWED_SYMBOLS")
+	if !ok {
+		symbols = "._-"
+	}
+
+	return symbols
+}
+
+func GetReservedUserNames() []string {
+	names := os.Getenv("RESERVED_USERNAMES")
+	if names == "" {
+		names = "admin,administrator,root,system,support,me"
+	}
+
+	return strings.Split(names, ",")
+}
+
+func GetPasswordMinLength() int {
+	return getInt("PASSWORD_MIN_LENGTH", 8)
+}
+
+func GetPasswordMaxLength() int {
+	return getInt("PASSWORD_MAX_LENGTH", 128)
+}
+
+func GetPasswordMinCharacterClasses() int {
+	return getInt("PASSWORD_MIN_CHARACTER_CLASSES", 1)
+}
+
+func GetPasswordBlocklistFile() string {
+	return os.Getenv("PASSWORD_BLOCKLIST_FILE")
+}
+
+func GetPasswordResetTokenTTL() time.Duration {
+	return getDuration("PASSWORD_RESET_TOKEN_TTL", 15*time.Minute)
+}
+
+func GetPollTimeout() time.Duration {
+	return getDuration("POLL_TIMEOUT", 25*time.Second)
+}
+
+func GetWsPingInterval() time.Duration {
+	return getDuration("WS_PING_INTERVAL", 5*time.Second)
+}
+
+func GetWsPongWait() time.Duration {
+	return getDuration("WS_PONG_WAIT", 15*time.Second)
+}
+
+// GetWsIdleTimeout returns 0, which disables the idle timeout, unless WS_IDLE_TIMEOUT is set.
+func GetWsIdleTimeout() time.Duration {
+	return getDuration("WS_IDLE_TIMEOUT", 0)
+}
+
+func GetWsSessionTTL() time.Duration {
+	return getDuration("WS_SESSION_TTL", 2*time.Minute)
+}
+
+func GetWsAuthTimeout() time.Duration {
+	return getDuration("WS_AUTH_TIMEOUT", 5*time.Second)
+}
+
+func GetWsMaxMessageSize() int64 {
+	return int64(getInt("WS_MAX_MESSAGE_SIZE", 64<<10))
+}
+
+// GetWsRateLimits returns the inbound WebSocket rates as type=perSecond:burst pairs, * applies to every other type
+// but ack, which keeps a rate of its own unless it is listed.
+func GetWsRateLimits() string {
+	rates := os.Getenv("WS_RATE_LIMITS")
+	if rates == "" {
+		rates = "*=10:20,message=5:10"
+	}
+
+	return rates
+}
+
+func GetWsCompression() bool {
+	return os.Getenv("WS_COMPRESSION") == "true"
+}
+
+func GetWsCompressionLevel() int {
+	return getInt("WS_COMPRESSION_LEVEL", 1)
+}
+
+// GetWsCompressionThreshold returns the size in bytes below which WebSocket messages are sent uncompressed.
+func GetWsCompressionThreshold() int {
+	return getInt("WS_COMPRESSION_THRESHOLD", 256)
+}
+
+func GetSendBufferSize() int {
+	return getInt("SEND_BUFFER_SIZE", 64)
+}
+
+// GetSendBufferPolicy returns drop_newest, drop_oldest or disconnect, the value is validated by the hub.
+func GetSendBufferPolicy() string {
+	policy := os.Getenv("SEND_BUFFER_POLICY")
+	if policy == "" {
+		policy = "drop_newest"
+	}
+
+	return policy
+}
+
+// GetRedisUrl returns the server relaying events between instances, events stay in the process when it is empty. Only
+// events and disconnects are shared, every instance keeps its own users and tokens.
+func GetRedisUrl() string {
+	return os.Getenv("REDIS_URL")
+}
+
+// GetPresenceInterval returns how often each instance announces its users to the others.
+func GetPresenceInterval() time.Duration {
+	return getDuration("PRESENCE_INTERVAL", 10*time.Second)
+}
+
+func GetNotifier() string {
+	notifier := os.Getenv("NOTIFIER")
+	if notifier == "" {
+		notifier = "log"
+	}
+
+	return notifier
+}
+
+func GetNotifierFile() string {
+	path := os.Getenv("NOTIFIER_FILE")
+	if path == "" {
+		path = "notifications.log"
+	}
+
+	return path
+}
+
+// GetNotifierLogTokens reports whether the log notifier writes reset tokens to the log, only for local development.
+func GetNotifierLogTokens() bool {
+	return os.Getenv("NOTIFIER_LOG_TOKENS") == "true"
+}
+
+func GetAdminUserName() string {
+	return os.Getenv("ADMIN_USERNAME")
+}
+
+func GetAdminPassword() string {
+	return os.Getenv("ADMIN_PASSWORD")
+}
+
+func getInt(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getDuration(name string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
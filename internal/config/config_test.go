@@ -4,6 +4,7 @@ import (
 	"httpserver/internal/config"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,3 +34,183 @@ func TestGetBaseUrl_EnvironmentVariable(t *testing.T) {
 	baseUrl := config.GetBaseUrl()
 	assert.Equal(t, "example.com", baseUrl, "Base URL from environment variable should be returned")
 }
+
+func TestGetTracingExporter_Default(t *testing.T) {
+	exporter := config.GetTracingExporter()
+	assert.Equal(t, "none", exporter, "Tracing should be disabled by default")
+}
+
+func TestGetTracingExporter_EnvironmentVariable(t *testing.T) {
+	os.Setenv("TRACING_EXPORTER", "otlp")
+	defer os.Unsetenv("TRACING_EXPORTER")
+
+	exporter := config.GetTracingExporter()
+	assert.Equal(t, "otlp", exporter, "Tracing exporter from environment variable should be returned")
+}
+
+func TestGetOtlpEndpoint_Default(t *testing.T) {
+	endpoint := config.GetOtlpEndpoint()
+	assert.Equal(t, "localhost:4318", endpoint, "Default OTLP endpoint should be localhost:4318")
+}
+
+func TestGetLegacyRoutesSunset_EnvironmentVariable(t *testing.T) {
+	os.Setenv("LEGACY_ROUTES_SUNSET", "2027-01-01T00:00:00Z")
+	defer os.Unsetenv("LEGACY_ROUTES_SUNSET")
+
+	sunset := config.GetLegacyRoutesSunset()
+	assert.Equal(t, "2027-01-01T00:00:00Z", sunset, "Legacy routes sunset from environment variable should be returned")
+}
+
+func TestGetLegacyRoutesDeprecation_EnvironmentVariable(t *testing.T) {
+	assert.Equal(t, "", config.GetLegacyRoutesDeprecation())
+
+	os.Setenv("LEGACY_ROUTES_DEPRECATION", "2026-11-01T00:00:00Z")
+	defer os.Unsetenv("LEGACY_ROUTES_DEPRECATION")
+
+	assert.Equal(t, "2026-11-01T00:00:00Z", config.GetLegacyRoutesDeprecation())
+}
+
+func TestGetUserNameMinLength_Default(t *testing.T) {
+	assert.Equal(t, 4, config.GetUserNameMinLength(), "Default username min length should be 4")
+}
+
+func TestGetPasswordMinLength_InvalidEnvironmentVariable(t *testing.T) {
+	os.Setenv("PASSWORD_MIN_LENGTH", "eight")
+	defer os.Unsetenv("PASSWORD_MIN_LENGTH")
+
+	assert.Equal(t, 8, config.GetPasswordMinLength(), "Invalid password min length should fall back to the default")
+}
+
+func TestGetReservedUserNames_EnvironmentVariable(t *testing.T) {
+	os.Setenv("RESERVED_USERNAMES", "root,bot")
+	defer os.Unsetenv("RESERVED_USERNAMES")
+
+	assert.Equal(t, []string{"root", "bot"}, config.GetReservedUserNames(), "Reserved usernames from environment variable should be returned")
+}
+
+func TestGetAllowedOrigins(t *testing.T) {
+	assert.Empty(t, config.GetAllowedOrigins())
+
+	os.Setenv("ALLOWED_ORIGINS", "https://app.example.com,http://localhost:3000")
+	defer os.Unsetenv("ALLOWED_ORIGINS")
+
+	assert.Equal(t, []string{"https://app.example.com", "http://localhost:3000"}, config.GetAllowedOrigins())
+}
+
+func TestGetPasswordResetTokenTTL(t *testing.T) {
+	assert.Equal(t, 15*time.Minute, config.GetPasswordResetTokenTTL())
+
+	os.Setenv("PASSWORD_RESET_TOKEN_TTL", "1h")
+	defer os.Unsetenv("PASSWORD_RESET_TOKEN_TTL")
+
+	assert.Equal(t, time.Hour, config.GetPasswordResetTokenTTL())
+}
+
+func TestGetPollTimeout(t *testing.T) {
+	assert.Equal(t, 25*time.Second, config.GetPollTimeout())
+
+	os.Setenv("POLL_TIMEOUT", "5s")
+	defer os.Unsetenv("POLL_TIMEOUT")
+
+	assert.Equal(t, 5*time.Second, config.GetPollTimeout())
+}
+
+func TestGetPresenceInterval(t *testing.T) {
+	assert.Equal(t, 10*time.Second, config.GetPresenceInterval())
+
+	os.Setenv("PRESENCE_INTERVAL", "2s")
+	defer os.Unsetenv("PRESENCE_INTERVAL")
+
+	assert.Equal(t, 2*time.Second, config.GetPresenceInterval())
+}
+
+func TestGetWsTimeouts(t *testing.T) {
+	assert.Equal(t, 5*time.Second, config.GetWsPingInterval())
+	assert.Equal(t, 15*time.Second, config.GetWsPongWait())
+	assert.Equal(t, time.Duration(0), config.GetWsIdleTimeout(), "Idle timeout should be disabled by default")
+	assert.Equal(t, 2*time.Minute, config.GetWsSessionTTL())
+	assert.Equal(t, 5*time.Second, config.GetWsAuthTimeout())
+
+	os.Setenv("WS_PING_INTERVAL", "1s")
+	os.Setenv("WS_PONG_WAIT", "3s")
+	os.Setenv("WS_IDLE_TIMEOUT", "10m")
+	os.Setenv("WS_SESSION_TTL", "invalid")
+	os.Setenv("WS_AUTH_TIMEOUT", "2s")
+	defer func() {
+		os.Unsetenv("WS_AUTH_TIMEOUT")
+		os.Unsetenv("WS_PING_INTERVAL")
+		os.Unsetenv("WS_PONG_WAIT")
+		os.Unsetenv("WS_IDLE_TIMEOUT")
+		os.Unsetenv("WS_SESSION_TTL")
+	}()
+
+	assert.Equal(t, time.Second, config.GetWsPingInterval())
+	assert.Equal(t, 3*time.Second, config.GetWsPongWait())
+	assert.Equal(t, 10*time.Minute, config.GetWsIdleTimeout())
+	assert.Equal(t, 2*time.Minute, config.GetWsSessionTTL(), "Invalid values should fall back to the default")
+	assert.Equal(t, 2*time.Second, config.GetWsAuthTimeout())
+}
+
+func TestGetWsLimits(t *testing.T) {
+	assert.Equal(t, int64(64<<10), config.GetWsMaxMessageSize())
+	assert.Equal(t, "*=10:20,message=5:10", config.GetWsRateLimits())
+
+	os.Setenv("WS_MAX_MESSAGE_SIZE", "1024")
+	os.Setenv("WS_RATE_LIMITS", "*=1:1")
+	defer func() {
+		os.Unsetenv("WS_MAX_MESSAGE_SIZE")
+		os.Unsetenv("WS_RATE_LIMITS")
+	}()
+
+	assert.Equal(t, int64(1024), config.GetWsMaxMessageSize())
+	assert.Equal(t, "*=1:1", config.GetWsRateLimits())
+}
+
+func TestGetWsCompression(t *testing.T) {
+	assert.False(t, config.GetWsCompression())
+	assert.Equal(t, 1, config.GetWsCompressionLevel())
+	assert.Equal(t, 256, config.GetWsCompressionThreshold())
+
+	os.Setenv("WS_COMPRESSION", "true")
+	os.Setenv("WS_COMPRESSION_LEVEL", "6")
+	os.Setenv("WS_COMPRESSION_THRESHOLD", "1024")
+	defer func() {
+		os.Unsetenv("WS_COMPRESSION")
+		os.Unsetenv("WS_COMPRESSION_LEVEL")
+		os.Unsetenv("WS_COMPRESSION_THRESHOLD")
+	}()
+
+	assert.True(t, config.GetWsCompression())
+	assert.Equal(t, 6, config.GetWsCompressionLevel())
+	assert.Equal(t, 1024, config.GetWsCompressionThreshold())
+}
+
+func TestGetSendBuffer(t *testing.T) {
+	assert.Equal(t, 64, config.GetSendBufferSize())
+	assert.Equal(t, "drop_newest", config.GetSendBufferPolicy())
+
+	os.Setenv("SEND_BUFFER_SIZE", "16")
+	os.Setenv("SEND_BUFFER_POLICY", "disconnect")
+	defer func() {
+		os.Unsetenv("SEND_BUFFER_SIZE")
+		os.Unsetenv("SEND_BUFFER_POLICY")
+	}()
+
+	assert.Equal(t, 16, config.GetSendBufferSize())
+	assert.Equal(t, "disconnect", config.GetSendBufferPolicy())
+}
+
+func TestGetRedisUrl(t *testing.T) {
+	assert.Equal(t, "", config.GetRedisUrl())
+
+	os.Setenv("REDIS_URL", "redis://localhost:6379/0")
+	defer os.Unsetenv("REDIS_URL")
+
+	assert.Equal(t, "redis://localhost:6379/0", config.GetRedisUrl())
+}
+
+func TestGetNotifier_Default(t *testing.T) {
+	assert.Equal(t, "log", config.GetNotifier())
+	assert.Equal(t, "notifications.log", config.GetNotifierFile())
+	assert.False(t, config.GetNotifierLogTokens())
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"httpserver/internal/logging"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage/activeuserstorage"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (c *Controller) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := requests.DecodePagination(r)
+	if err != nil {
+		logging.FromContext(r.Context()).Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	users, total := c.UserStorage.List(r.Context(), page.Offset, page.Limit)
+	responseData := responses.AdminUserListResponse{
+		Users:  make([]responses.AdminUserResponse, 0, len(users)),
+		Total:  total,
+		Offset: page.Offset,
+		Limit:  page.Limit,
+	}
+	for _, user := range users {
+		responseData.Users = append(responseData.Users, responses.NewAdminUserResponse(user))
+	}
+
+	writeJSON(w, http.StatusOK, responseData)
+}
+
+func (c *Controller) AdminBanUser(w http.ResponseWriter, r *http.Request) {
+	c.setBanned(w, r, true)
+}
+
+func (c *Controller) AdminUnbanUser(w http.ResponseWriter, r *http.Request) {
+	c.setBanned(w, r, false)
+}
+
+func (c *Controller) setBanned(w http.ResponseWriter, r *http.Request, banned bool) {
+	logger := logging.FromContext(r.Context())
+	user, err := c.UserStorage.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	updated := *user
+	updated.Banned = banned
+	if err := c.UserStorage.Update(r.Context(), &updated); err != nil {
+		logger.Error(err.Error())
+		writeError(w, userStorageErrorStatus(err), err.Error())
+		return
+	}
+
+	if banned {
+		c.TokenStorage.DeleteByUser(r.Context(), updated.Uuid)
+		c.SessionStorage.DeleteByUser(r.Context(), updated.Uuid)
+		c.ResetTokenStorage.DeleteByUser(r.Context(), updated.Uuid)
+		c.disconnect(r, updated.Uuid)
+	}
+	logger.Infow("user ban changed", "target", updated.UserName, "banned", banned)
+
+	writeJSON(w, http.StatusOK, responses.NewAdminUserResponse(&updated))
+}
+
+func (c *Controller) AdminListSessions(w http.ResponseWriter, r *http.Request) {
+	activeUsers, _ := c.ActiveUsersStorage.List(r.Context(), activeuserstorage.Query{})
+	responseData := make([]responses.ActiveSessionResponse, 0, len(activeUsers))
+	for _, activeUser := range activeUsers {
+		responseData = append(responseData, responses.ActiveSessionResponse{
+			Id:          activeUser.User.Uuid,
+			UserName:    activeUser.User.UserName,
+			OnlineSince: activeUser.OnlineSince,
+			Connections: c.Hub.Count(activeUser.User.Uuid),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, responseData)
+}
+
+func (c *Controller) AdminDisconnectUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !c.Hub.Online(id) {
+		writeError(w, http.StatusNotFound, "user has no live connection")
+		return
+	}
+
+	disconnected := c.disconnect(r, id)
+	logging.FromContext(r.Context()).Infow("user disconnected", "target", id, "disconnected", disconnected)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) disconnect(r *http.Request, id string) int {
+	c.ActiveUsersStorage.Delete(r.Context(), id)
+
+	return c.Hub.Disconnect(id)
+}
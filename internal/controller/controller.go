@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"httpserver/internal/cors"
+	"httpserver/internal/hub"
+	"httpserver/internal/notifier"
+	"httpserver/internal/policy"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/resettokenstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	defaultPasswordResetTTL = 15 * time.Minute
+	defaultPollTimeout      = 25 * time.Second
+
+	// sessionTTL is how long the session token returned by login is accepted.
+	sessionTTL = time.Hour
+)
+
+type Controller struct {
+	UserStorage        userstorage.UserStorageInterface
+	TokenStorage       tokenstorage.TokenStorageInterface
+	ActiveUsersStorage activeuserstorage.ActiveUsersStorageInterface
+	SessionStorage     sessionstorage.SessionStorageInterface
+	ResetTokenStorage  resettokenstorage.ResetTokenStorageInterface
+	StreamTokenStorage sessionstorage.SessionStorageInterface
+	Hub                *hub.Hub
+	Policy             *policy.Policy
+	Notifier           notifier.Notifier
+	PasswordResetTTL   time.Duration
+	PollTimeout        time.Duration
+	WsTimeouts         WsTimeouts
+	WsLimits           WsLimits
+	WsCompression      WsCompression
+	AllowedOrigins     cors.Origins
+	Clock              clock.Clock
+}
+
+func (c *Controller) policy() *policy.Policy {
+	if c.Policy == nil {
+		return policy.Default()
+	}
+
+	return c.Policy
+}
+
+func (c *Controller) notifier() notifier.Notifier {
+	if c.Notifier == nil {
+		return notifier.LogNotifier{}
+	}
+
+	return c.Notifier
+}
+
+func (c *Controller) passwordResetTTL() time.Duration {
+	if c.PasswordResetTTL <= 0 {
+		return defaultPasswordResetTTL
+	}
+
+	return c.PasswordResetTTL
+}
+
+func (c *Controller) pollTimeout() time.Duration {
+	if c.PollTimeout <= 0 {
+		return defaultPollTimeout
+	}
+
+	return c.PollTimeout
+}
+
+func (c *Controller) wsTimeouts() WsTimeouts {
+	return c.WsTimeouts.withDefaults()
+}
+
+func (c *Controller) wsLimits() WsLimits {
+	return c.WsLimits.withDefaults()
+}
+
+func (c *Controller) wsCompression() WsCompression {
+	return c.WsCompression.withDefaults()
+}
+
+func (c *Controller) clock() clock.Clock {
+	if c.Clock == nil {
+		return clock.New()
+	}
+
+	return c.Clock
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"httpserver/internal/auth"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetry             = 3 * time.Second
+
+	// streamTokenTTL bounds how long a stream token opens new connections, open streams are not closed when it expires.
+	streamTokenTTL = 15 * time.Minute
+)
+
+// CreateStreamToken issues a token for the token query parameter of /events that, unlike the one-time login token,
+// still works when EventSource reconnects with the same URL.
+func (c *Controller) CreateStreamToken(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusServiceUnavailable, "token could not be generated")
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(streamTokenTTL)
+	c.StreamTokenStorage.Add(r.Context(), token, user.Uuid, expiresAt)
+
+	writeJSON(w, http.StatusCreated, responses.StreamTokenResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+func (c *Controller) Events(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, err := c.streamUser(r)
+	if err != nil {
+		logger.Error(err.Error())
+		auth.Unauthorized(w)
+		return
+	}
+	logging.SetUser(r.Context(), user.UserName)
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Error("response writer does not support flushing")
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	subscription := c.Hub.Subscribe(user.Uuid, lastEventID)
+	defer subscription.Close()
+	connection := &streamConnection{cancel: cancel}
+	c.connect(ctx, user, connection)
+	defer c.release(r.Context(), user, connection)
+	metrics.SseActiveConnections.Inc()
+	defer metrics.SseActiveConnections.Dec()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if err := subscription.Err(); err != nil {
+					logger.Warnw("closing event stream", "reason", err.Error())
+				}
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		}
+		flusher.Flush()
+	}
+}
+
+func (c *Controller) SendMessage(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	var body requests.MessageRequest
+	if err := requests.Decode(r, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	event, err := c.publishMessage(r.Context(), user, body)
+	if err != nil {
+		logger.Error(err.Error())
+		status := http.StatusInternalServerError
+		if errors.Is(err, errRecipientNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, responses.MessageResponse{Id: event.ID, Time: event.Time})
+}
+
+// parseLastEventID reads the header set by EventSource on reconnect, or a lastEventId query parameter for clients that cannot set headers.
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+type streamConnection struct {
+	cancel context.CancelFunc
+}
+
+func (c *streamConnection) Close() error {
+	c.cancel()
+	return nil
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"httpserver/internal/auth"
+	"httpserver/internal/logging"
+	"httpserver/internal/requests"
+	"httpserver/internal/storage"
+	"net/http"
+	"time"
+)
+
+const newPasswordField = "newPassword"
+
+func (c *Controller) UserChangePassword(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	var body requests.PasswordChangeRequest
+	if err := requests.Decode(r, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+	if body.CurrentPassword != user.Password {
+		logger.Error("invalid current password")
+		writeError(w, http.StatusForbidden, "invalid current password")
+		return
+	}
+	if err := c.validateNewPassword(body.NewPassword); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	if err := c.setPassword(r.Context(), *user, body.NewPassword); err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.SessionStorage.DeleteByUser(r.Context(), user.Uuid)
+	// The user just proved they know the password, so the session they used starts a new lifetime.
+	c.SessionStorage.Add(r.Context(), auth.BearerToken(r), user.Uuid, time.Now().UTC().Add(sessionTTL))
+	c.TokenStorage.DeleteByUser(r.Context(), user.Uuid)
+	disconnected := c.disconnect(r, user.Uuid)
+	logger.Infow("password changed", "user", user.UserName, "disconnected", disconnected)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) UserRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	var body requests.PasswordResetRequest
+	if err := requests.Decode(r, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	// Every outcome is answered with 202 Accepted so the endpoint cannot be used to enumerate accounts.
+	if err := c.requestPasswordReset(r.Context(), body.UserName); err != nil {
+		logger.Error(err.Error())
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *Controller) requestPasswordReset(ctx context.Context, userName string) error {
+	user, err := c.UserStorage.Get(ctx, userName)
+	if err != nil {
+		return err
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(c.passwordResetTTL())
+	c.ResetTokenStorage.Add(ctx, token, user.Uuid, expiresAt)
+	if err := c.notifier().NotifyPasswordReset(ctx, user, token, expiresAt); err != nil {
+		c.ResetTokenStorage.DeleteByUser(ctx, user.Uuid)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Controller) UserResetPassword(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	var body requests.PasswordResetConfirmRequest
+	if err := requests.Decode(r, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	// The token is used up by Get, so a password the policy rejects must not get that far.
+	if err := c.validateNewPassword(body.NewPassword); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+	userID, err := c.ResetTokenStorage.Get(r.Context(), body.Token)
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusBadRequest, "invalid or expired reset token")
+		return
+	}
+
+	user, err := c.UserStorage.GetByID(r.Context(), userID)
+	if err == nil {
+		err = c.setPassword(r.Context(), *user, body.NewPassword)
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusBadRequest, "invalid or expired reset token")
+		return
+	}
+
+	c.SessionStorage.DeleteByUser(r.Context(), user.Uuid)
+	c.TokenStorage.DeleteByUser(r.Context(), user.Uuid)
+	disconnected := c.disconnect(r, user.Uuid)
+	logging.SetUser(r.Context(), user.UserName)
+	logger.Infow("password reset", "user", user.UserName, "disconnected", disconnected)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) validateNewPassword(password string) error {
+	errs := c.policy().ValidatePassword(password)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for i := range errs {
+		errs[i].Field = newPasswordField
+	}
+
+	return errs
+}
+
+func (c *Controller) setPassword(ctx context.Context, user storage.User, password string) error {
+	user.Password = password
+	if err := c.UserStorage.Update(ctx, &user); err != nil {
+		return err
+	}
+	c.ResetTokenStorage.DeleteByUser(ctx, user.Uuid)
+
+	return nil
+}
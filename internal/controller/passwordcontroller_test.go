@@ -0,0 +1,65 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+
+	"httpserver/internal/controller"
+	"httpserver/internal/logging"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/resettokenstorage"
+	"httpserver/internal/storage/userstorage"
+)
+
+type failingNotifier struct{}
+
+func (failingNotifier) NotifyPasswordReset(ctx context.Context, user *storage.User, token string, expiresAt time.Time) error {
+	return errors.New("mocked error")
+}
+
+func TestUserRequestPasswordReset_NotifierFailure(t *testing.T) {
+	userStorage := userstorage.NewUserStorage()
+	userStorage.Add(context.Background(), "JohnDoe", "password123")
+	ctrl := &controller.Controller{
+		UserStorage:       userStorage,
+		ResetTokenStorage: resettokenstorage.NewResetTokenStorage(),
+		Notifier:          failingNotifier{},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/user/password/reset", strings.NewReader(`{"userName":"JohnDoe"}`))
+	rr := httptest.NewRecorder()
+	ctrl.UserRequestPasswordReset(rr, req.WithContext(logging.NewContext(req.Context(), zaptest.NewLogger(t).Sugar())))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+}
+
+func TestUserResetPassword_InvalidToken(t *testing.T) {
+	ctrl := &controller.Controller{
+		UserStorage:       userstorage.NewUserStorage(),
+		ResetTokenStorage: resettokenstorage.NewResetTokenStorage(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/user/password/reset/confirm", strings.NewReader(`{"token":"abc123","newPassword":"newpassword123"}`))
+	rr := httptest.NewRecorder()
+	ctrl.UserResetPassword(rr, req.WithContext(logging.NewContext(req.Context(), zaptest.NewLogger(t).Sugar())))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error":"invalid or expired reset token"}`, rr.Body.String())
+}
+
+func TestUserChangePassword_Unauthorized(t *testing.T) {
+	ctrl := &controller.Controller{}
+
+	rr := httptest.NewRecorder()
+	ctrl.UserChangePassword(rr, httptest.NewRequest(http.MethodPost, "/user/me/password", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
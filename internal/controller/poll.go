@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"httpserver/internal/auth"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage"
+	"net/http"
+)
+
+// Poll holds the request until events newer than since arrive or the poll timeout passes. The first poll consumes the
+// one-time login token and keeps a queue under it, so later polls with the same token resume that queue. Session
+// bearer tokens keep their own queue. Every poll is authenticated again, see pollUser.
+func (c *Controller) Poll(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	query, err := requests.DecodePoll(r)
+	if err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+	since := uint64(query.Since)
+
+	key := auth.BearerToken(r)
+	if key == "" {
+		key = r.URL.Query().Get("token")
+	}
+
+	queue, ok := c.Hub.PollQueue(key)
+	user, err := c.pollUser(r, queue)
+	if err != nil {
+		logger.Error(err.Error())
+		if ok {
+			queue.Close()
+		}
+		auth.Unauthorized(w)
+		return
+	}
+	logging.SetUser(r.Context(), user.UserName)
+	if !ok {
+		queue = c.openPollQueue(r.Context(), key, user, since)
+	}
+
+	events, err := queue.Poll(r.Context(), since, c.pollTimeout())
+	switch {
+	case errors.Is(err, hub.ErrPollInProgress):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, hub.ErrPollClosed):
+		auth.Unauthorized(w)
+		return
+	case errors.Is(err, hub.ErrSlowConsumer):
+		logger.Warnw("poll queue closed", "reason", err.Error())
+		writeError(w, http.StatusGone, err.Error())
+		return
+	}
+
+	response := responses.PollResponse{Events: []hub.Event{}, LastEventId: since}
+	if len(events) > 0 {
+		response.Events = events
+		response.LastEventId = events[len(events)-1].ID
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// pollUser authenticates a poll. Session bearer and stream tokens are checked like on any stream. The one-time login
+// token is used up by the poll that opened its queue, so later polls with it load the user of the queue again and are
+// refused once the user is deleted or banned.
+func (c *Controller) pollUser(r *http.Request, queue *hub.PollQueue) (*storage.User, error) {
+	user, err := c.streamUser(r)
+	if err != nil && queue != nil && auth.BearerToken(r) == "" {
+		user, err = c.UserStorage.GetByID(r.Context(), queue.UserID())
+		if err == nil && user.Banned {
+			err = errUserBanned
+		}
+	}
+	if err == nil && queue != nil && queue.UserID() != user.Uuid {
+		err = errors.New("poll queue belongs to another user")
+	}
+
+	return user, err
+}
+
+// openPollQueue counts the queue as a connection while it lives, it is closed when it is not polled for twice the
+// poll timeout or when the user is disconnected.
+func (c *Controller) openPollQueue(ctx context.Context, key string, user *storage.User, since uint64) *hub.PollQueue {
+	var queue *hub.PollQueue
+	queue, created := c.Hub.OpenPollQueue(key, user.Uuid, since, 2*c.pollTimeout(), func() {
+		c.release(context.Background(), user, queue)
+		metrics.PollActiveQueues.Dec()
+	})
+	if created {
+		c.connect(ctx, user, queue)
+		metrics.PollActiveQueues.Inc()
+	}
+
+	return queue
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"httpserver/internal/auth"
+	"httpserver/internal/logging"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage/userstorage"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (c *Controller) UserGetProfile(w http.ResponseWriter, r *http.Request) {
+	user, err := c.UserStorage.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		logging.FromContext(r.Context()).Error(err.Error())
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, responses.NewUserProfileResponse(user))
+}
+
+func (c *Controller) UserGetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, responses.NewUserProfileResponse(user))
+}
+
+func (c *Controller) UserUpdateMe(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	var body requests.UserUpdateRequest
+	if err := requests.Decode(r, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+
+	updated := *user
+	if body.UserName != nil {
+		if errs := c.policy().ValidateUserName(*body.UserName); len(errs) > 0 {
+			logger.Error(errs.Error())
+			writeRequestError(w, errs)
+			return
+		}
+		updated.UserName = *body.UserName
+	}
+	if body.DisplayName != nil {
+		updated.DisplayName = *body.DisplayName
+	}
+	if body.AvatarUrl != nil {
+		updated.AvatarUrl = *body.AvatarUrl
+	}
+	if body.Bio != nil {
+		updated.Bio = *body.Bio
+	}
+	if body.StatusText != nil {
+		updated.StatusText = *body.StatusText
+	}
+
+	if err := c.UserStorage.Update(r.Context(), &updated); err != nil {
+		logger.Error(err.Error())
+		writeError(w, userStorageErrorStatus(err), err.Error())
+		return
+	}
+	stored, err := c.UserStorage.GetByID(r.Context(), user.Uuid)
+	if err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	logging.SetUser(r.Context(), stored.UserName)
+
+	writeJSON(w, http.StatusOK, responses.NewUserProfileResponse(stored))
+}
+
+func (c *Controller) UserDeleteMe(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		auth.Unauthorized(w)
+		return
+	}
+
+	if err := c.UserStorage.Delete(r.Context(), user.Uuid); err != nil {
+		logger.Error(err.Error())
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.TokenStorage.DeleteByUser(r.Context(), user.Uuid)
+	c.SessionStorage.DeleteByUser(r.Context(), user.Uuid)
+	disconnected := c.disconnect(r, user.Uuid)
+	logger.Infow("user deleted", "user", user.UserName, "disconnected", disconnected)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func userStorageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, userstorage.ErrUserNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, userstorage.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, responses.ErrorResponse{Error: message})
+}
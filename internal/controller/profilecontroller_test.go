@@ -0,0 +1,121 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"httpserver/internal/auth"
+	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+)
+
+type fakeConnection struct {
+	closed bool
+}
+
+func (c *fakeConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestUserGetProfile_NotFound(t *testing.T) {
+	ctrl := &controller.Controller{UserStorage: userstorage.NewUserStorage()}
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "unknown")
+	req := httptest.NewRequest(http.MethodGet, "/user/unknown", nil)
+	ctx := context.WithValue(logging.NewContext(req.Context(), zaptest.NewLogger(t).Sugar()), chi.RouteCtxKey, routeContext)
+	rr := httptest.NewRecorder()
+
+	ctrl.UserGetProfile(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUserGetMe_Unauthorized(t *testing.T) {
+	ctrl := &controller.Controller{}
+
+	rr := httptest.NewRecorder()
+	ctrl.UserGetMe(rr, httptest.NewRequest(http.MethodGet, "/user/me", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestUserUpdateMe(t *testing.T) {
+	userStorage := userstorage.NewUserStorage()
+	id, _ := userStorage.Add(context.Background(), "JohnDoe", "password123")
+	user, _ := userStorage.GetByID(context.Background(), id)
+	ctrl := &controller.Controller{UserStorage: userStorage}
+
+	req := httptest.NewRequest(http.MethodPatch, "/user/me", strings.NewReader(`{"statusText":"Busy","avatarUrl":"https://example.com/a.png"}`))
+	ctx := auth.NewContext(logging.NewContext(req.Context(), zaptest.NewLogger(t).Sugar()), user)
+	rr := httptest.NewRecorder()
+
+	ctrl.UserUpdateMe(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response responses.UserProfileResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, "Busy", response.StatusText)
+	assert.Equal(t, "https://example.com/a.png", response.AvatarUrl)
+
+	stored, _ := userStorage.GetByID(context.Background(), id)
+	assert.Equal(t, "Busy", stored.StatusText)
+}
+
+func TestUserDeleteMe(t *testing.T) {
+	userStorage := userstorage.NewUserStorage()
+	tokenStorage := tokenstorage.NewTokenStorage()
+	sessionStorage := sessionstorage.NewSessionStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(userStorage)
+	connections := hub.New()
+
+	id, _ := userStorage.Add(context.Background(), "JohnDoe", "password123")
+	user, _ := userStorage.GetByID(context.Background(), id)
+	tokenStorage.Add(context.Background(), "ws-token", id)
+	sessionStorage.Add(context.Background(), "session-token", id, time.Now().Add(time.Hour))
+	activeUsersStorage.Add(context.Background(), id)
+
+	connection := &fakeConnection{}
+	connections.Register(id, connection)
+
+	ctrl := &controller.Controller{
+		UserStorage:        userStorage,
+		TokenStorage:       tokenStorage,
+		SessionStorage:     sessionStorage,
+		ActiveUsersStorage: activeUsersStorage,
+		Hub:                connections,
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/user/me", nil)
+	ctx := auth.NewContext(logging.NewContext(req.Context(), zaptest.NewLogger(t).Sugar()), user)
+	rr := httptest.NewRecorder()
+
+	ctrl.UserDeleteMe(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	_, err := userStorage.GetByID(context.Background(), id)
+	assert.Error(t, err)
+	_, err = tokenStorage.Get(context.Background(), "ws-token")
+	assert.Error(t, err)
+	_, err = sessionStorage.Get(context.Background(), "session-token")
+	assert.Error(t, err)
+	assert.Empty(t, activeUsersStorage.GetNames(context.Background()))
+	assert.True(t, connection.closed)
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"httpserver/internal/auth"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/requests"
+	"httpserver/internal/storage"
+	"io"
+	"net/http"
+)
+
+var (
+	errRecipientNotFound = errors.New("recipient does not exist")
+	errUserBanned        = errors.New("user is banned")
+)
+
+// streamUser authenticates a streaming connection with a session bearer token, a reusable stream token, or the
+// one-time token used by /ws.
+func (c *Controller) streamUser(r *http.Request) (*storage.User, error) {
+	var userID string
+	var err error
+	if token := auth.BearerToken(r); token != "" {
+		userID, err = c.SessionStorage.Get(r.Context(), token)
+	} else {
+		token = r.URL.Query().Get("token")
+		if userID, err = c.StreamTokenStorage.Get(r.Context(), token); err != nil {
+			userID, err = c.TokenStorage.Get(r.Context(), token)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.UserStorage.GetByID(r.Context(), userID)
+	if err == nil && user.Banned {
+		err = errUserBanned
+	}
+
+	return user, err
+}
+
+// connect registers a live connection of any transport and announces the user when it is the first one.
+func (c *Controller) connect(ctx context.Context, user *storage.User, conn io.Closer) {
+	connections := c.Hub.Register(user.Uuid, conn)
+	c.ActiveUsersStorage.Add(ctx, user.Uuid)
+	if connections == 1 {
+		c.publishPresence(ctx, user, hub.PresenceOnline)
+	}
+}
+
+// release unregisters a live connection and announces the user offline when it was the last one of the user on any
+// instance.
+func (c *Controller) release(ctx context.Context, user *storage.User, conn io.Closer) {
+	if c.Hub.Unregister(user.Uuid, conn) == 0 && !c.Hub.Online(user.Uuid) {
+		c.ActiveUsersStorage.Delete(ctx, user.Uuid)
+		c.publishPresence(ctx, user, hub.PresenceOffline)
+	}
+}
+
+// RemoteEvent keeps the active users of this instance in line with the presence announced by the other instances.
+func (c *Controller) RemoteEvent(ctx context.Context, event hub.Event) {
+	if event.Type != hub.EventPresence {
+		return
+	}
+
+	var data hub.PresenceData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return
+	}
+	switch data.Status {
+	case hub.PresenceOnline:
+		c.ActiveUsersStorage.Add(ctx, data.UserID)
+	case hub.PresenceOffline:
+		if !c.Hub.Online(data.UserID) {
+			c.ActiveUsersStorage.Delete(ctx, data.UserID)
+		}
+	}
+}
+
+func (c *Controller) publishPresence(ctx context.Context, user *storage.User, status string) {
+	data := hub.PresenceData{UserID: user.Uuid, UserName: user.UserName, Status: status}
+	if _, err := c.Hub.Publish(hub.EventPresence, user.Uuid, "", data); err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+	}
+}
+
+func (c *Controller) publishMessage(ctx context.Context, user *storage.User, message requests.MessageRequest) (hub.Event, error) {
+	// A recipient registered on another instance is only known by their presence.
+	if message.To != "" && !c.Hub.Online(message.To) {
+		if _, err := c.UserStorage.GetByID(ctx, message.To); err != nil {
+			return hub.Event{}, errRecipientNotFound
+		}
+	}
+
+	data := hub.MessageData{From: user.Uuid, FromName: user.UserName, To: message.To, Text: message.Text}
+	return c.Hub.Publish(hub.EventMessage, user.Uuid, message.To, data)
+}
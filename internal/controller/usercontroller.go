@@ -2,59 +2,83 @@ package controller
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"httpserver/internal/config"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/requests"
 	"httpserver/internal/responses"
 	"httpserver/internal/storage/activeuserstorage"
-	"httpserver/internal/storage/tokenstorage"
-	"httpserver/internal/storage/userstorage"
+	"httpserver/internal/validation"
 	"net/http"
+	"strings"
 	"time"
 
-	"go.uber.org/zap"
+	"github.com/go-chi/chi/v5"
 )
 
-func UserHandler(writer http.ResponseWriter, request *http.Request, userStorage userstorage.UserStorageInterface, logger *zap.SugaredLogger) {
-	userName, password, err := getUsernameAndPasswordFromBody(request)
+const ruleCursor = "cursor"
+
+func (c *Controller) UserHandler(writer http.ResponseWriter, request *http.Request) {
+	logger := logging.FromContext(request.Context())
+	var body requests.UserRequest
+	if err := requests.Decode(request, &body); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(writer, err)
+		return
+	}
+	if err := c.policy().Validate(body.UserName, body.Password); err != nil {
+		logger.Error(err.Error())
+		writeRequestError(writer, err)
+		return
+	}
+	id, err := c.UserStorage.Add(request.Context(), body.UserName, body.Password)
 	if err != nil {
 		logger.Error(err.Error())
-		writer.WriteHeader(http.StatusBadRequest)
-		writer.Write([]byte(err.Error()))
+		writeError(writer, userStorageErrorStatus(err), err.Error())
 		return
 	}
-	id := userStorage.Add(userName, password)
-	responseData := &responses.UserResponse{Id: id, UserName: userName}
+	metrics.UserRegistrationsTotal.Inc()
+	responseData := &responses.UserResponse{Id: id, UserName: body.UserName}
 
 	writer.WriteHeader(http.StatusCreated)
 	encoder := json.NewEncoder(writer)
 	encoder.Encode(responseData)
 }
 
-func UserLoginHandler(
-	writer http.ResponseWriter,
-	request *http.Request,
-	userStorage userstorage.UserStorageInterface,
-	logger *zap.SugaredLogger,
-	tokenStorage tokenstorage.TokenStorageInterface,
-) {
-	userName, password, err := getUsernameAndPasswordFromBody(request)
-	if err != nil {
+func (c *Controller) UserLoginHandler(writer http.ResponseWriter, request *http.Request) {
+	logger := logging.FromContext(request.Context())
+	var body requests.UserLoginRequest
+	if err := requests.Decode(request, &body); err != nil {
 		logger.Error(err.Error())
-		writer.WriteHeader(http.StatusBadRequest)
+		metrics.UserLoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+		writeRequestError(writer, err)
 		return
 	}
 
-	user, err := userStorage.Get(userName)
-	if err != nil || user.Password != password {
+	user, err := c.UserStorage.Get(request.Context(), body.UserName)
+	if err == nil && user.Password != body.Password {
+		err = errors.New("invalid password")
+	}
+	if err != nil {
 		logger.Error(err.Error())
+		metrics.UserLoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 		writer.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if user.Banned {
+		logger.Errorw("user is banned", "user", user.UserName)
+		metrics.UserLoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+		writeError(writer, http.StatusForbidden, "user is banned")
+		return
+	}
+
+	logging.SetUser(request.Context(), user.UserName)
 
-	currentTime := time.Now().UTC()
-	currentTime = currentTime.Add(time.Hour * 1)
+	expiresAt := time.Now().UTC().Add(sessionTTL)
 
 	token, err := generateSecureToken()
 	if err != nil {
@@ -62,49 +86,87 @@ func UserLoginHandler(
 		writer.WriteHeader(http.StatusServiceUnavailable)
 		return
 	}
+	sessionToken, err := generateSecureToken()
+	if err != nil {
+		logger.Error(err.Error())
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 
-	tokenStorage.Add(token, user)
+	c.TokenStorage.Add(request.Context(), token, user.Uuid)
+	c.SessionStorage.Add(request.Context(), sessionToken, user.Uuid, expiresAt)
+	metrics.UserLoginsTotal.WithLabelValues(metrics.LoginSuccess).Inc()
 
-	url := "ws://" + config.GetBaseUrl() + config.GetPort() + "/ws?token=" + token
-	responseData := responses.UserLoginResponse{Url: url}
+	url := "ws://" + config.GetBaseUrl() + config.GetPort() + routePrefix(request, "/user/login") + "/ws"
+	responseData := responses.UserLoginResponse{Url: url, WsToken: token, Token: sessionToken}
 	writer.Header().Add("X-Rate-Limit", "60")
-	writer.Header().Add("X-Expires-After", currentTime.String())
+	writer.Header().Add("X-Expires-After", expiresAt.String())
 	writer.WriteHeader(http.StatusCreated)
 	encoder := json.NewEncoder(writer)
 	encoder.SetEscapeHTML(false)
 	encoder.Encode(responseData)
 }
 
-func UserGetActiveList(w http.ResponseWriter, activeUsersStorage activeuserstorage.ActiveUsersStorageInterface) {
+func (c *Controller) UserGetActiveList(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	encoder := json.NewEncoder(w)
-	encoder.Encode(activeUsersStorage.GetNames())
+	encoder.Encode(c.ActiveUsersStorage.GetNames(r.Context()))
 }
 
-func getUsernameAndPasswordFromBody(request *http.Request) (string, string, error) {
-	decoder := json.NewDecoder(request.Body)
-	var body = make(map[string]string)
-	err := decoder.Decode(&body)
+func (c *Controller) UserListActive(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	query, err := requests.DecodeActiveUserList(r)
 	if err != nil {
-		return "", "", errors.New("invalid body")
+		logger.Error(err.Error())
+		writeRequestError(w, err)
+		return
+	}
+	after, err := decodeCursor(query.Cursor)
+	if err != nil {
+		logger.Error(err.Error())
+		writeRequestError(w, validation.Errors{{Field: "cursor", Rule: ruleCursor, Message: "cursor is invalid"}})
+		return
 	}
 
-	userName, userNameOk := body["userName"]
-	password, passwordOk := body["password"]
-
-	if !userNameOk || !passwordOk {
-		return "", "", errors.New("invalid username or password")
+	// Fetch one extra entry to know whether another page exists without a second lookup.
+	activeUsers, total := c.ActiveUsersStorage.List(r.Context(), activeuserstorage.Query{Prefix: query.Prefix, After: after, Limit: query.Limit + 1})
+	responseData := responses.ActiveUserListResponse{Users: make([]responses.ActiveUserResponse, 0, query.Limit), Total: total}
+	if len(activeUsers) > query.Limit {
+		activeUsers = activeUsers[:query.Limit]
+		responseData.NextCursor = encodeCursor(activeUsers[len(activeUsers)-1].User.UserName)
+	}
+	for _, activeUser := range activeUsers {
+		responseData.Users = append(responseData.Users, responses.NewActiveUserResponse(activeUser))
 	}
 
-	if len(userName) < 4 {
-		return "", "", errors.New("username should be 4 chars or longer")
+	writeJSON(w, http.StatusOK, responseData)
+}
+
+func encodeCursor(userName string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(userName))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	return string(after), err
+}
+
+func writeRequestError(writer http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, requests.ErrBodyTooLarge) {
+		status = http.StatusRequestEntityTooLarge
 	}
 
-	if len(password) < 8 {
-		return "", "", errors.New("password should be 8 chars or longer")
+	responseData := responses.ErrorResponse{Error: err.Error()}
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		responseData = responses.ErrorResponse{Error: "validation failed", Fields: fieldErrors}
 	}
 
-	return userName, password, nil
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	encoder := json.NewEncoder(writer)
+	encoder.Encode(responseData)
 }
 
 func generateSecureToken() (string, error) {
@@ -114,3 +176,12 @@ func generateSecureToken() (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+func routePrefix(request *http.Request, route string) string {
+	routeContext := chi.RouteContext(request.Context())
+	if routeContext == nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(routeContext.RoutePattern(), route)
+}
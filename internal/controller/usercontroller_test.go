@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -8,27 +9,51 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
 
 	"httpserver/internal/controller"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/policy"
+	"httpserver/internal/responses"
 	"httpserver/internal/storage"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/sessionstorage"
 	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/validation"
 )
 
 type UserStorageMock struct {
 }
 
-func (m UserStorageMock) Add(userName string, password string) string {
-	return "mocked_id"
+func (m UserStorageMock) Add(ctx context.Context, userName string, password string) (string, error) {
+	return "mocked_id", nil
 }
 
-func (m UserStorageMock) Get(userName string) (*storage.User, error) {
+func (m UserStorageMock) Get(ctx context.Context, userName string) (*storage.User, error) {
 	return &storage.User{UserName: "JohnDoe", Password: "password123", Uuid: "mocked_id"}, nil
 }
 
+func (m UserStorageMock) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	return &storage.User{UserName: "JohnDoe", Password: "password123", Uuid: "mocked_id"}, nil
+}
+
+func (m UserStorageMock) Update(ctx context.Context, user *storage.User) error {
+	return nil
+}
+
+func (m UserStorageMock) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m UserStorageMock) List(ctx context.Context, offset int, limit int) ([]*storage.User, int) {
+	return nil, 0
+}
+
 func TestUserHandler(t *testing.T) {
 	userStorage := new(UserStorageMock)
 
@@ -36,16 +61,18 @@ func TestUserHandler(t *testing.T) {
 
 	reqBody := `{"userName": "JohnDoe", "password": "password123"}`
 	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
 	w := httptest.NewRecorder()
 
-	controller.UserHandler(w, req, userStorage, logger)
+	ctrl := &controller.Controller{UserStorage: userStorage}
+	ctrl.UserHandler(w, req)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	expectedResBody := `{"id":"mocked_id","userName":"JohnDoe"}`
 	assert.Equal(t, expectedResBody, strings.TrimSpace(w.Body.String()))
 
-	user, err := userStorage.Get("JohnDoe")
+	user, err := userStorage.Get(context.Background(), "JohnDoe")
 	assert.NoError(t, err)
 	assert.Equal(t, "JohnDoe", user.UserName)
 	assert.Equal(t, "password123", user.Password)
@@ -58,23 +85,21 @@ func TestUserLoginHandler(t *testing.T) {
 
 	reqBody := `{"userName": "JohnDoe","password": "password123"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger, tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
-	expectedURL := "ws://localhost:3000/ws?token="
-	assert.Contains(t, w.Body.String(), expectedURL)
-
 	var response map[string]string
 	json.Unmarshal(w.Body.Bytes(), &response)
-	token := strings.TrimSpace(strings.TrimPrefix(response[`url`], expectedURL))
+	assert.Equal(t, "ws://localhost:3000/ws", response["url"])
 
-	user, err := tokenStorage.Get(token)
+	userID, err := tokenStorage.Get(context.Background(), response["wsToken"])
 	assert.NoError(t, err)
-	assert.Equal(t, "JohnDoe", user.UserName)
-	assert.Equal(t, "password123", user.Password)
+	assert.Equal(t, "mocked_id", userID)
 }
 
 func TestUserLoginHandler_InvalidBody(t *testing.T) {
@@ -91,9 +116,11 @@ func TestUserLoginHandler_InvalidBody(t *testing.T) {
 
 	reqBody := `{"userName": "JohnDoe","password": "password12`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
@@ -101,7 +128,7 @@ func TestUserLoginHandler_InvalidBody(t *testing.T) {
 	assert.Contains(t, logs, "invalid body")
 }
 
-func TestUserLoginHandler_ShortUserName(t *testing.T) {
+func TestUserLoginHandler_ShortPassword(t *testing.T) {
 	userStorage := new(UserStorageMock)
 	buf := &zaptest.Buffer{}
 
@@ -113,19 +140,21 @@ func TestUserLoginHandler_ShortUserName(t *testing.T) {
 
 	tokenStorage := tokenstorage.NewTokenStorage()
 
-	reqBody := `{"userName": "Jon","password": "password123"}`
+	reqBody := `{"userName": "John","password": "pass"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
 	logs := buf.String()
-	assert.Contains(t, logs, "username should be 4 chars or longer")
+	assert.Contains(t, logs, "invalid password")
 }
 
-func TestUserLoginHandler_ShortPassword(t *testing.T) {
+func TestUserLoginHandler_UserNameNotProvided(t *testing.T) {
 	userStorage := new(UserStorageMock)
 	buf := &zaptest.Buffer{}
 
@@ -137,19 +166,21 @@ func TestUserLoginHandler_ShortPassword(t *testing.T) {
 
 	tokenStorage := tokenstorage.NewTokenStorage()
 
-	reqBody := `{"userName": "John","password": "pass"}`
+	reqBody := `{"password": "pass"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
 	logs := buf.String()
-	assert.Contains(t, logs, "password should be 8 chars or longer")
+	assert.Contains(t, logs, "username is required")
 }
 
-func TestUserLoginHandler_UserNameNotProvided(t *testing.T) {
+func TestUserLoginHandler_PasswordNotProvided(t *testing.T) {
 	userStorage := new(UserStorageMock)
 	buf := &zaptest.Buffer{}
 
@@ -161,19 +192,21 @@ func TestUserLoginHandler_UserNameNotProvided(t *testing.T) {
 
 	tokenStorage := tokenstorage.NewTokenStorage()
 
-	reqBody := `{"password": "pass"}`
+	reqBody := `{"userName": "JohnDoe"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
 	logs := buf.String()
-	assert.Contains(t, logs, "invalid username or password")
+	assert.Contains(t, logs, "password is required")
 }
 
-func TestUserLoginHandler_PasswordNotProvided(t *testing.T) {
+func TestUserLoginHandler_WrongPassword(t *testing.T) {
 	userStorage := new(UserStorageMock)
 	buf := &zaptest.Buffer{}
 
@@ -184,30 +217,132 @@ func TestUserLoginHandler_PasswordNotProvided(t *testing.T) {
 	))
 
 	tokenStorage := tokenstorage.NewTokenStorage()
+	failures := metrics.UserLoginsTotal.WithLabelValues(metrics.LoginFailure)
+	before := testutil.ToFloat64(failures)
 
-	reqBody := `{"userName": "JohnDoe"}`
+	reqBody := `{"userName": "JohnDoe","password": "password456"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
 
 	logs := buf.String()
-	assert.Contains(t, logs, "invalid username or password")
+	assert.Contains(t, logs, "invalid password")
+}
+
+func TestUserHandler_AggregatesFieldErrors(t *testing.T) {
+	userStorage := new(UserStorageMock)
+	logger := zaptest.NewLogger(t).Sugar()
+
+	reqBody := `{"userName": "Jo n", "password": "pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
+	w := httptest.NewRecorder()
+
+	ctrl := &controller.Controller{UserStorage: userStorage}
+	ctrl.UserHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response responses.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation failed", response.Error)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "userName", Rule: "charset", Message: `username may only contain letters, digits and "._-"`},
+		{Field: "password", Rule: "min", Message: "password should be 8 chars or longer"},
+	}, response.Fields)
+}
+
+func TestUserHandler_PolicyFromController(t *testing.T) {
+	userStorage := new(UserStorageMock)
+	logger := zaptest.NewLogger(t).Sugar()
+
+	userPolicy := policy.Default()
+	userPolicy.PasswordMinClasses = 3
+	userPolicy.PasswordBlocklist = policy.NewSet([]string{"password123"})
+
+	reqBody := `{"userName": "Admin", "password": "password123"}`
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
+	w := httptest.NewRecorder()
+
+	ctrl := &controller.Controller{UserStorage: userStorage, Policy: userPolicy}
+	ctrl.UserHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response responses.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	rules := []string{}
+	for _, fieldError := range response.Fields {
+		rules = append(rules, fieldError.Field+":"+fieldError.Rule)
+	}
+	assert.Equal(t, []string{"userName:reserved", "password:complexity", "password:blocklist"}, rules)
+}
+
+func TestUserHandler_UnknownField(t *testing.T) {
+	userStorage := new(UserStorageMock)
+	logger := zaptest.NewLogger(t).Sugar()
+
+	reqBody := `{"userName": "JohnDoe", "password": "password123", "admin": true}`
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
+	w := httptest.NewRecorder()
+
+	ctrl := &controller.Controller{UserStorage: userStorage}
+	ctrl.UserHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown field admin")
+}
+
+func TestUserHandler_BodyTooLarge(t *testing.T) {
+	userStorage := new(UserStorageMock)
+	logger := zaptest.NewLogger(t).Sugar()
+
+	reqBody := `{"userName": "JohnDoe", "password": "` + strings.Repeat("a", 8<<10) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger))
+	w := httptest.NewRecorder()
+
+	ctrl := &controller.Controller{UserStorage: userStorage}
+	ctrl.UserHandler(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
 }
 
 type UserStorageInvalidUserMock struct {
 }
 
-func (m UserStorageInvalidUserMock) Add(userName string, password string) string {
-	return "mocked_id"
+func (m UserStorageInvalidUserMock) Add(ctx context.Context, userName string, password string) (string, error) {
+	return "mocked_id", nil
 }
 
-func (m UserStorageInvalidUserMock) Get(userName string) (*storage.User, error) {
+func (m UserStorageInvalidUserMock) Get(ctx context.Context, userName string) (*storage.User, error) {
 	return nil, errors.New("mocked error")
 }
 
+func (m UserStorageInvalidUserMock) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	return nil, errors.New("mocked error")
+}
+
+func (m UserStorageInvalidUserMock) Update(ctx context.Context, user *storage.User) error {
+	return nil
+}
+
+func (m UserStorageInvalidUserMock) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m UserStorageInvalidUserMock) List(ctx context.Context, offset int, limit int) ([]*storage.User, int) {
+	return nil, 0
+}
+
 func TestUserLoginHandler_UserDoesNotExist(t *testing.T) {
 	userStorage := new(UserStorageInvalidUserMock)
 	buf := &zaptest.Buffer{}
@@ -222,9 +357,11 @@ func TestUserLoginHandler_UserDoesNotExist(t *testing.T) {
 
 	reqBody := `{"userName": "John","password": "password123"}`
 	req := httptest.NewRequest(http.MethodPost, "/user/login", strings.NewReader(reqBody))
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.UserLoginHandler(w, req, userStorage, logger.Sugar(), tokenStorage)
+	ctrl := &controller.Controller{UserStorage: userStorage, TokenStorage: tokenStorage, SessionStorage: sessionstorage.NewSessionStorage()}
+	ctrl.UserLoginHandler(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
@@ -234,31 +371,36 @@ func TestUserLoginHandler_UserDoesNotExist(t *testing.T) {
 
 type fakeActiveUsersStorage struct{}
 
-func (m *fakeActiveUsersStorage) GetNames() []string {
+func (m *fakeActiveUsersStorage) GetNames(ctx context.Context) []string {
 	return []string{"User1", "User2", "User3"}
 }
 
-func (m *fakeActiveUsersStorage) Add(user *storage.User) {
+func (m *fakeActiveUsersStorage) Add(ctx context.Context, userID string) {
 }
 
-func (m *fakeActiveUsersStorage) Get(userName string) (*storage.User, error) {
+func (m *fakeActiveUsersStorage) Get(ctx context.Context, userName string) (*storage.User, error) {
 	return nil, nil
 }
 
-func (m *fakeActiveUsersStorage) Delete(user *storage.User) {
+func (m *fakeActiveUsersStorage) Delete(ctx context.Context, userID string) {
+}
+
+func (m *fakeActiveUsersStorage) List(ctx context.Context, query activeuserstorage.Query) ([]activeuserstorage.ActiveUser, int) {
+	return nil, 0
 }
 
 func TestUserGetActiveList(t *testing.T) {
 	activeUsersStorage := &fakeActiveUsersStorage{}
 
-	_, err := http.NewRequest("GET", "/users/active", nil)
+	req, err := http.NewRequest("GET", "/users/active", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	rr := httptest.NewRecorder()
 
-	controller.UserGetActiveList(rr, activeUsersStorage)
+	ctrl := &controller.Controller{ActiveUsersStorage: activeUsersStorage}
+	ctrl.UserGetActiveList(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Errorf("expected status code %d but got %d", http.StatusOK, rr.Code)
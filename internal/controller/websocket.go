@@ -2,37 +2,350 @@ package controller
 
 import (
 	"context"
-	"httpserver/internal/storage/activeuserstorage"
-	"httpserver/internal/storage/tokenstorage"
+	"encoding/json"
+	"errors"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/protocol"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"httpserver/internal/storage"
+	"httpserver/internal/tracing"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/pkgz/websocket"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
-func Ws(
-	w http.ResponseWriter,
-	r *http.Request,
-	tokenStorage tokenstorage.TokenStorageInterface,
-	activeUsersStorage activeuserstorage.ActiveUsersStorageInterface,
-	logger *zap.SugaredLogger,
-) {
-	user, err := tokenStorage.Get(r.URL.Query().Get("token"))
+const (
+	EventMessageSent = "message.sent"
+	EventAck         = "ack"
+
+	wsUnknownEvent = "unknown"
+)
+
+func (c *Controller) Ws(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	if !c.AllowedOrigins.CheckOrigin(r) {
+		logger.Warnw("websocket origin not allowed", "origin", r.Header.Get("Origin"))
+		writeError(w, http.StatusForbidden, "origin not allowed")
+		return
+	}
+
+	var user *storage.User
+	var session *hub.Session
+	var resumed bool
+	if credentials := wsCredentials(r); credentials != (protocol.AuthPayload{}) {
+		var err error
+		user, session, resumed, err = c.wsSession(r.Context(), credentials)
+		if err != nil {
+			logger.Error(err.Error())
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	version, codec, subprotocol, err := protocol.Negotiate(websocket.Subprotocols(r))
 	if err != nil {
 		logger.Error(err.Error())
-		w.WriteHeader(http.StatusUnauthorized)
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	wsServer := websocket.Start(context.Background())
-	activeUsersStorage.Add(user)
-	wsServer.Handler(w, r)
-	wsServer.On("echo", func(c *websocket.Conn, msg *websocket.Message) {
-		err = c.Emit("echo", msg.Data)
+	header := http.Header{}
+	if subprotocol != "" {
+		header.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+	compression := c.wsCompression()
+	conn, err := wsUpgrader(compression).Upgrade(w, r, header)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	timeouts, limits := c.wsTimeouts(), c.wsLimits()
+	conn.SetReadLimit(limits.MaxMessageSize)
+	connection := newWsConnection(conn, c.clock(), timeouts, compression, codec)
+	defer connection.Close()
+
+	if session == nil {
+		user, session, resumed, err = c.wsAuthenticate(r.Context(), connection, timeouts.AuthTimeout)
 		if err != nil {
 			logger.Error(err.Error())
+			return
+		}
+	}
+	logging.SetUser(r.Context(), user.UserName)
+
+	subscription, unacked, err := session.Attach(connection)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	defer session.Detach(connection)
+	defer subscription.Close()
+
+	c.connect(r.Context(), user, connection)
+	metrics.WebsocketActiveConnections.Inc()
+	defer func() {
+		metrics.WebsocketActiveConnections.Dec()
+		c.release(r.Context(), user, connection)
+	}()
+
+	welcome, _ := protocol.NewEnvelope(protocol.EventWelcome, protocol.WelcomePayload{
+		Version:      version,
+		Versions:     protocol.SupportedVersions,
+		UserID:       user.Uuid,
+		SessionToken: session.Token(),
+		Resumed:      resumed,
+	})
+	if err := connection.send(welcome); err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	for _, event := range unacked {
+		connection.reply(logger, eventEnvelope(event))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go connection.keepAlive(c.clock().Ticker(timeouts.PingInterval), logger, done)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		forwardEvents(connection, session, subscription, logger)
+	}()
+	// Forwarding stops before the user is released, so its own offline presence is not written to the closing
+	// connection after Ws returns.
+	defer func() {
+		subscription.Close()
+		<-forwarded
+	}()
+
+	registry := c.wsRegistry(user, session)
+	limiter := newWsLimiter(limits, c.clock())
+	for {
+		data, err := connection.read()
+		if errors.Is(err, websocket.ErrReadLimit) {
+			logger.Warnw("closing websocket connection", "reason", wsReasonMessageTooBig)
+			metrics.WebsocketDisconnectsTotal.WithLabelValues(wsReasonMessageTooBig).Inc()
+		}
+		if err != nil {
+			return
+		}
+		c.handleWsMessage(r.Context(), logger, connection, limiter, registry, data)
+	}
+}
+
+// wsUpgrader accepts every origin, Ws checks it against the allowed origins before reading credentials.
+func wsUpgrader(compression WsCompression) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		CheckOrigin:       func(r *http.Request) bool { return true },
+		EnableCompression: compression.Enabled,
+	}
+}
+
+// wsCredentials prefers the credentials offered as subprotocols, the token and session query parameters remain for
+// older clients although they end up in the access logs of proxies.
+func wsCredentials(r *http.Request) protocol.AuthPayload {
+	credentials := protocol.Credentials(websocket.Subprotocols(r))
+	if credentials == (protocol.AuthPayload{}) {
+		credentials = protocol.AuthPayload{Token: r.URL.Query().Get("token"), Session: r.URL.Query().Get("session")}
+	}
+
+	return credentials
+}
+
+// wsSession resumes the session named by the credentials, or opens a new one for their one-time token.
+func (c *Controller) wsSession(ctx context.Context, credentials protocol.AuthPayload) (*storage.User, *hub.Session, bool, error) {
+	if credentials.Session != "" {
+		session, ok := c.Hub.Session(credentials.Session)
+		if !ok {
+			return nil, nil, false, errSessionNotFound
+		}
+		user, err := c.UserStorage.GetByID(ctx, session.UserID())
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if user.Banned {
+			return nil, nil, false, errors.New("user is banned")
+		}
+
+		return user, session, true, nil
+	}
+
+	userID, err := c.TokenStorage.Get(ctx, credentials.Token)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	user, err := c.UserStorage.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	token, err := generateSecureToken()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return user, c.Hub.OpenSession(token, user.Uuid, c.wsTimeouts().SessionTTL), false, nil
+}
+
+// wsAuthenticate waits for the auth envelope of a connection opened without credentials. The connection is closed
+// with the policy violation code when the envelope does not arrive within the timeout or its credentials are rejected.
+func (c *Controller) wsAuthenticate(ctx context.Context, connection *wsConnection, timeout time.Duration) (*storage.User, *hub.Session, bool, error) {
+	timer := c.clock().AfterFunc(timeout, func() {
+		metrics.WebsocketDisconnectsTotal.WithLabelValues(wsReasonAuthTimeout).Inc()
+		connection.close(websocket.ClosePolicyViolation, wsReasonAuthTimeout)
+	})
+	data, err := connection.read()
+	if !timer.Stop() {
+		return nil, nil, false, errAuthTimeout
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	user, session, resumed, err := c.wsAuth(ctx, connection.codec, data)
+	if err != nil {
+		metrics.WebsocketDisconnectsTotal.WithLabelValues(wsReasonAuthFailed).Inc()
+		connection.close(websocket.ClosePolicyViolation, wsReasonAuthFailed)
+	}
+
+	return user, session, resumed, err
+}
+
+func (c *Controller) wsAuth(ctx context.Context, codec protocol.Codec, data []byte) (*storage.User, *hub.Session, bool, error) {
+	envelope, err := protocol.Decode(codec, data)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if envelope.Type != protocol.EventAuth {
+		return nil, nil, false, errors.New("first envelope should be auth")
+	}
+	var credentials protocol.AuthPayload
+	if err := json.Unmarshal(envelope.Payload, &credentials); err != nil {
+		return nil, nil, false, err
+	}
+
+	return c.wsSession(ctx, credentials)
+}
+
+func (c *Controller) wsRegistry(user *storage.User, session *hub.Session) *protocol.Registry {
+	registry := protocol.NewRegistry()
+	protocol.Handle(registry, "echo", "echo", func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		return payload, nil
+	})
+	protocol.Handle(registry, EventAck, "", func(ctx context.Context, ack requests.AckRequest) (interface{}, error) {
+		id, err := strconv.ParseUint(ack.Id, 10, 64)
+		if err != nil {
+			return nil, protocol.NewError(protocol.CodeInvalidPayload, "id should be a server message id")
 		}
+		session.Ack(id)
+
+		return nil, nil
 	})
+	protocol.Handle(registry, hub.EventMessage, EventMessageSent, func(ctx context.Context, message requests.MessageRequest) (interface{}, error) {
+		envelope, _ := protocol.EnvelopeFromContext(ctx)
+		event, ok := session.Published(envelope.ID)
+		if !ok {
+			var err error
+			event, err = c.publishMessage(ctx, user, message)
+			if errors.Is(err, errRecipientNotFound) {
+				return nil, protocol.NewError(protocol.CodeNotFound, err.Error())
+			}
+			if err != nil {
+				return nil, err
+			}
+			if envelope.ID != "" {
+				session.RememberPublished(envelope.ID, event)
+			}
+		}
 
-	activeUsersStorage.Delete(user)
+		return responses.MessageResponse{Id: event.ID, Time: event.Time}, nil
+	})
+
+	return registry
 }
+
+func (c *Controller) handleWsMessage(ctx context.Context, logger *zap.SugaredLogger, connection *wsConnection, limiter *wsLimiter, registry *protocol.Registry, data []byte) {
+	envelope, err := protocol.Decode(connection.codec, data)
+	eventType := envelope.Type
+	if err != nil || !registry.Has(eventType) {
+		eventType = wsUnknownEvent
+	}
+	metrics.WebsocketMessagesTotal.WithLabelValues(metrics.DirectionIn, eventType).Inc()
+
+	if action := limiter.check(eventType); action != "" {
+		limitWsMessage(logger, connection, limiter, action, eventType, envelope.ID)
+		return
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		connection.reply(logger, protocol.ErrorEnvelope(err, envelope.ID))
+		return
+	}
+
+	eventCtx, span := tracing.StartEvent(ctx, eventType)
+	defer span.End()
+
+	reply, err := registry.Dispatch(eventCtx, envelope)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Error(err.Error())
+		connection.reply(logger, protocol.ErrorEnvelope(err, envelope.ID))
+		return
+	}
+	if reply != nil {
+		connection.reply(logger, *reply)
+	}
+}
+
+// limitWsMessage drops an envelope over the rate of its type and answers with the action of the limiter.
+func limitWsMessage(logger *zap.SugaredLogger, connection *wsConnection, limiter *wsLimiter, action string, eventType string, id string) {
+	metrics.WebsocketRateLimitedTotal.WithLabelValues(eventType, action).Inc()
+	switch action {
+	case wsActionWarn:
+		logger.Warnw("websocket rate limit exceeded", "event", eventType)
+		connection.reply(logger, protocol.WarningEnvelope(protocol.CodeRateLimited, "too many "+eventType+" events, slow down", id))
+	case wsActionMute:
+		logger.Warnw("websocket connection muted", "event", eventType, "duration", limiter.limits.MuteDuration)
+		connection.reply(logger, protocol.WarningEnvelope(protocol.CodeMuted, limiter.muteMessage(), id))
+	case wsActionDisconnect:
+		logger.Warnw("closing websocket connection", "reason", wsReasonPolicyViolation, "event", eventType)
+		metrics.WebsocketDisconnectsTotal.WithLabelValues(wsReasonPolicyViolation).Inc()
+		connection.close(websocket.ClosePolicyViolation, wsReasonPolicyViolation)
+	}
+}
+
+// forwardEvents sends hub events until the subscription ends, a subscription dropped for falling behind closes the
+// connection so that the client resumes the session and receives the missed events again.
+func forwardEvents(connection *wsConnection, session *hub.Session, subscription *hub.Subscription, logger *zap.SugaredLogger) {
+	for event := range subscription.Events() {
+		session.Sent(event)
+		connection.reply(logger, eventEnvelope(event))
+	}
+	if errors.Is(subscription.Err(), hub.ErrSlowConsumer) {
+		logger.Warnw("closing websocket connection", "reason", wsReasonSlowConsumer)
+		metrics.WebsocketDisconnectsTotal.WithLabelValues(wsReasonSlowConsumer).Inc()
+		connection.close(websocket.CloseTryAgainLater, wsReasonSlowConsumer)
+	}
+}
+
+func eventEnvelope(event hub.Event) protocol.Envelope {
+	return protocol.Envelope{
+		Type:    event.Type,
+		ID:      strconv.FormatUint(event.ID, 10),
+		Ts:      event.Time,
+		Payload: event.Data,
+	}
+}
+
+var (
+	errConnectionClosed = errors.New("connection closed")
+	errSessionNotFound  = errors.New("session does not exist")
+	errAuthTimeout      = errors.New("auth envelope was not received in time")
+)
@@ -1,16 +1,28 @@
 package controller_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/protocol"
 	"httpserver/internal/storage"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/tracing"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
@@ -18,51 +30,83 @@ import (
 
 type mockTokenStorage struct{}
 
-func (m *mockTokenStorage) Get(token string) (*storage.User, error) {
+func (m *mockTokenStorage) Get(ctx context.Context, token string) (string, error) {
 	if token == "valid_token" {
-		return &storage.User{UserName: "JohnDoe"}, nil
+		return "1", nil
 	}
-	return nil, errors.New("invalid token")
+	return "", errors.New("invalid token")
 }
-func (m *mockTokenStorage) Add(string, *storage.User) {
+func (m *mockTokenStorage) Add(context.Context, string, string) {
 
 }
 
-func (m *mockTokenStorage) Delete(token string) {
+func (m *mockTokenStorage) Delete(ctx context.Context, token string) {
 
 }
 
+func (m *mockTokenStorage) DeleteByUser(ctx context.Context, userID string) {
+
+}
+
+// mockUserStorage only knows the user of valid_token.
+type mockUserStorage struct {
+	UserStorageInvalidUserMock
+}
+
+func (m *mockUserStorage) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	if id == "1" {
+		return &storage.User{UserName: "JohnDoe", Uuid: "1"}, nil
+	}
+	return nil, errors.New("mocked error")
+}
+
 type mockActiveUsersStorage struct {
-	addedUser   *storage.User
-	deletedUser *storage.User
+	mu          sync.Mutex
+	addedUser   string
+	deletedUser string
 }
 
-func (m *mockActiveUsersStorage) Add(user *storage.User) {
-	m.addedUser = user
+func (m *mockActiveUsersStorage) Add(ctx context.Context, userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addedUser = userID
 }
 
-func (m *mockActiveUsersStorage) Get(userName string) (*storage.User, error) {
+func (m *mockActiveUsersStorage) Get(ctx context.Context, userName string) (*storage.User, error) {
 	return nil, nil
 }
 
-func (m *mockActiveUsersStorage) GetNames() []string {
+func (m *mockActiveUsersStorage) GetNames(ctx context.Context) []string {
 	return nil
 }
 
-func (m *mockActiveUsersStorage) Delete(user *storage.User) {
-	m.deletedUser = user
+func (m *mockActiveUsersStorage) Delete(ctx context.Context, userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deletedUser = userID
+}
+
+func (m *mockActiveUsersStorage) users() (string, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addedUser, m.deletedUser
+}
+
+func (m *mockActiveUsersStorage) List(ctx context.Context, query activeuserstorage.Query) ([]activeuserstorage.ActiveUser, int) {
+	return nil, 0
 }
 
 func TestWs_ValidToken(t *testing.T) {
 	tokenStorage := &mockTokenStorage{}
-	activeUsersStorage := &mockActiveUsersStorage{
-		addedUser:   &storage.User{},
-		deletedUser: &storage.User{},
-	}
+	activeUsersStorage := &mockActiveUsersStorage{}
 	logger := zaptest.NewLogger(t).Sugar()
 
+	ctrl := &controller.Controller{TokenStorage: tokenStorage, ActiveUsersStorage: activeUsersStorage, UserStorage: &mockUserStorage{}, Hub: hub.New()}
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		controller.Ws(w, r, tokenStorage, activeUsersStorage, logger)
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), logger)))
 	}))
 	defer server.Close()
 
@@ -75,13 +119,10 @@ func TestWs_ValidToken(t *testing.T) {
 
 	conn.Close()
 
-	assert.NotNil(t, activeUsersStorage.addedUser)
-	assert.Equal(t, "JohnDoe", activeUsersStorage.addedUser.UserName)
-
-	time.Sleep(100 * time.Millisecond)
-
-	assert.NotNil(t, activeUsersStorage.deletedUser)
-	assert.Equal(t, "JohnDoe", activeUsersStorage.deletedUser.UserName)
+	assert.Eventually(t, func() bool {
+		added, deleted := activeUsersStorage.users()
+		return added == "1" && deleted == "1"
+	}, time.Second, 10*time.Millisecond)
 }
 
 func TestWs_InvalidToken(t *testing.T) {
@@ -94,18 +135,154 @@ func TestWs_InvalidToken(t *testing.T) {
 	))
 
 	fakeTokenStorage := &mockTokenStorage{}
-	fakeActiveUsersStorage := &mockActiveUsersStorage{
-		addedUser:   &storage.User{},
-		deletedUser: &storage.User{},
-	}
+	fakeActiveUsersStorage := &mockActiveUsersStorage{}
 
 	req := httptest.NewRequest("GET", "/ws?token=invalid_token", nil)
+	req = req.WithContext(logging.NewContext(req.Context(), logger.Sugar()))
 	w := httptest.NewRecorder()
 
-	controller.Ws(w, req, fakeTokenStorage, fakeActiveUsersStorage, logger.Sugar())
+	ctrl := &controller.Controller{TokenStorage: fakeTokenStorage, ActiveUsersStorage: fakeActiveUsersStorage, UserStorage: &mockUserStorage{}, Hub: hub.New()}
+	ctrl.Ws(w, req)
 
 	logs := buf.String()
 	assert.Contains(t, logs, "invalid token")
 
 	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
 }
+
+func TestWs_EchoCreatesEventSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	tokenStorage := &mockTokenStorage{}
+	activeUsersStorage := &mockActiveUsersStorage{}
+	logger := zaptest.NewLogger(t).Sugar()
+
+	ctrl := &controller.Controller{TokenStorage: tokenStorage, ActiveUsersStorage: activeUsersStorage, UserStorage: &mockUserStorage{}, Hub: hub.New()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), logger)))
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","id":"1","payload":"hello"}`))
+	assert.NoError(t, err)
+
+	echo := readEnvelope(t, conn, "echo")
+	assert.Equal(t, "1", echo.CorrelationID)
+	assert.JSONEq(t, `"hello"`, string(echo.Payload))
+
+	assert.Eventually(t, func() bool {
+		return len(exporter.GetSpans()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "ws.event echo", exporter.GetSpans()[0].Name)
+}
+
+func readEnvelope(t *testing.T, conn *websocket.Conn, eventType string) protocol.Envelope {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var envelope protocol.Envelope
+		require.NoError(t, conn.ReadJSON(&envelope))
+		if envelope.Type == eventType {
+			return envelope
+		}
+	}
+}
+
+func newWsServer(t *testing.T) *httptest.Server {
+	logger := zaptest.NewLogger(t).Sugar()
+	ctrl := &controller.Controller{
+		TokenStorage:       &mockTokenStorage{},
+		ActiveUsersStorage: &mockActiveUsersStorage{},
+		UserStorage:        &mockUserStorage{},
+		Hub:                hub.New(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), logger)))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestWs_NegotiatesProtocolVersion(t *testing.T) {
+	server := newWsServer(t)
+	dialer := websocket.Dialer{Subprotocols: []string{"other", protocol.Subprotocol(protocol.Version1)}}
+
+	conn, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, protocol.Subprotocol(protocol.Version1), resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	welcome := readEnvelope(t, conn, protocol.EventWelcome)
+	var payload protocol.WelcomePayload
+	require.NoError(t, json.Unmarshal(welcome.Payload, &payload))
+	assert.Equal(t, protocol.Version1, payload.Version)
+}
+
+func TestWs_RejectsUnsupportedProtocolVersion(t *testing.T) {
+	server := newWsServer(t)
+	dialer := websocket.Dialer{Subprotocols: []string{"httpserver.v99"}}
+
+	_, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestWs_ErrorEvents(t *testing.T) {
+	server := newWsServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tests := []struct {
+		message string
+		code    string
+	}{
+		{`not json`, protocol.CodeMalformedEnvelope},
+		{`{"id":"1","payload":{}}`, protocol.CodeMalformedEnvelope},
+		{`{"type":"dance","id":"2"}`, protocol.CodeUnknownType},
+		{`{"type":"message","id":"3","payload":{"text":1}}`, protocol.CodeInvalidPayload},
+		{`{"type":"message","id":"4","payload":{"text":""}}`, protocol.CodeValidationFailed},
+		{`{"type":"message","id":"5","payload":{"text":"hi","to":"missing"}}`, protocol.CodeNotFound},
+		{`{"type":"ack","id":"6","payload":{"id":"abc"}}`, protocol.CodeInvalidPayload},
+	}
+	for _, tt := range tests {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(tt.message)))
+
+		envelope := readEnvelope(t, conn, protocol.EventError)
+		var payload protocol.Error
+		require.NoError(t, json.Unmarshal(envelope.Payload, &payload))
+		assert.Equal(t, tt.code, payload.Code, tt.message)
+	}
+}
+
+func TestWs_MessageIsAcknowledgedAndBroadcast(t *testing.T) {
+	server := newWsServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"message","id":"7","payload":{"text":"hello"}}`)))
+
+	envelopes := map[string]protocol.Envelope{}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for len(envelopes) < 2 {
+		var envelope protocol.Envelope
+		require.NoError(t, conn.ReadJSON(&envelope))
+		if envelope.Type == controller.EventMessageSent || envelope.Type == hub.EventMessage {
+			envelopes[envelope.Type] = envelope
+		}
+	}
+
+	assert.Equal(t, "7", envelopes[controller.EventMessageSent].CorrelationID)
+	assert.NotEmpty(t, envelopes[hub.EventMessage].ID)
+	assert.JSONEq(t, `{"from":"1","fromName":"JohnDoe","text":"hello"}`, string(envelopes[hub.EventMessage].Payload))
+}
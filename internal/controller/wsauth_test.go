@@ -0,0 +1,99 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/controller"
+	"httpserver/internal/protocol"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWs_TokenSubprotocol(t *testing.T) {
+	server := newWsServer(t)
+	dialer := websocket.Dialer{Subprotocols: []string{protocol.Subprotocol(protocol.Version1), protocol.TokenSubprotocol("valid_token")}}
+
+	conn, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, protocol.Subprotocol(protocol.Version1), resp.Header.Get("Sec-WebSocket-Protocol"), "The token should not be echoed")
+
+	readEnvelope(t, conn, protocol.EventWelcome)
+}
+
+func TestWs_TokenSubprotocolRejected(t *testing.T) {
+	server := newWsServer(t)
+	dialer := websocket.Dialer{Subprotocols: []string{protocol.TokenSubprotocol("invalid_token")}}
+
+	_, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws", nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWs_AuthEnvelope(t *testing.T) {
+	server := newWsServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"auth","payload":{"token":"valid_token"}}`)))
+
+	welcome := readEnvelope(t, conn, protocol.EventWelcome)
+	var payload protocol.WelcomePayload
+	require.NoError(t, json.Unmarshal(welcome.Payload, &payload))
+	assert.NotEmpty(t, payload.SessionToken)
+}
+
+func TestWs_AuthEnvelopeRejected(t *testing.T) {
+	server := newWsServer(t)
+	tests := []string{
+		`{"type":"auth","payload":{"token":"invalid_token"}}`,
+		`{"type":"echo","payload":"hello"}`,
+		`not json`,
+	}
+
+	for _, message := range tests {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+		requireClosed(t, conn, websocket.ClosePolicyViolation)
+		conn.Close()
+	}
+}
+
+func TestWs_AuthTimeout(t *testing.T) {
+	server, mock, _ := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsTimeouts = controller.WsTimeouts{AuthTimeout: 2 * time.Second}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		closed <- err
+	}()
+
+	var readErr error
+	assert.Eventually(t, func() bool {
+		select {
+		case readErr = <-closed:
+			return true
+		default:
+			mock.Add(time.Second)
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, readErr, &closeErr)
+	assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	assert.Equal(t, "authentication timeout", closeErr.Text)
+}
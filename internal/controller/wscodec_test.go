@@ -0,0 +1,63 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/protocol"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBinaryEnvelope(t *testing.T, conn *websocket.Conn, codec protocol.Codec, eventType string) protocol.Envelope {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		messageType, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, websocket.BinaryMessage, messageType)
+		envelope, err := codec.Unmarshal(data)
+		require.NoError(t, err)
+		if envelope.Type == eventType {
+			return envelope
+		}
+	}
+}
+
+func TestWs_BinaryCodecs(t *testing.T) {
+	for _, codec := range []protocol.Codec{protocol.MsgPack, protocol.Protobuf} {
+		codec := codec
+		t.Run(codec.Name(), func(t *testing.T) { testBinaryCodec(t, codec) })
+	}
+}
+
+func testBinaryCodec(t *testing.T, codec protocol.Codec) {
+	server := newWsServer(t)
+	subprotocol := protocol.CodecSubprotocol(protocol.Version1, codec)
+	dialer := websocket.Dialer{Subprotocols: []string{subprotocol, protocol.Subprotocol(protocol.Version1)}}
+	conn, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, subprotocol, resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	welcome := readBinaryEnvelope(t, conn, codec, protocol.EventWelcome)
+	var payload protocol.WelcomePayload
+	require.NoError(t, json.Unmarshal(welcome.Payload, &payload))
+	assert.Equal(t, protocol.Version1, payload.Version)
+
+	echo := protocol.Envelope{Type: "echo", ID: "1", Ts: time.Now(), Payload: json.RawMessage(`{"text":"hello","count":3}`)}
+	data, err := codec.Marshal(echo)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data))
+
+	reply := readBinaryEnvelope(t, conn, codec, "echo")
+	assert.Equal(t, "1", reply.CorrelationID, codec.Name())
+	assert.JSONEq(t, `{"text":"hello","count":3}`, string(reply.Payload), codec.Name())
+
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte(`{"type":"echo"}`)))
+	failure := readBinaryEnvelope(t, conn, codec, protocol.EventError)
+	assert.Contains(t, string(failure.Payload), protocol.CodeMalformedEnvelope, codec.Name())
+}
@@ -0,0 +1,132 @@
+package controller_test
+
+import (
+	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/protocol"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingConn counts the bytes read from the wire, before decompression.
+type countingConn struct {
+	net.Conn
+	read *int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(c.read, int64(n))
+	return n, err
+}
+
+func dialCounting(tb testing.TB, h *hub.Hub, compression controller.WsCompression) (*websocket.Conn, *http.Response, *int64) {
+	ctrl := &controller.Controller{
+		TokenStorage:       &mockTokenStorage{},
+		ActiveUsersStorage: &mockActiveUsersStorage{},
+		UserStorage:        &mockUserStorage{},
+		Hub:                h,
+		WsCompression:      compression,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), zap.NewNop().Sugar())))
+	}))
+	tb.Cleanup(server.Close)
+
+	read := new(int64)
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		NetDial: func(network string, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			return countingConn{Conn: conn, read: read}, err
+		},
+	}
+	conn, resp, err := dialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { conn.Close() })
+
+	return conn, resp, read
+}
+
+func echoWireSize(t *testing.T, compression controller.WsCompression, text string) int64 {
+	conn, _, read := dialCounting(t, hub.New(), compression)
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	before := atomic.LoadInt64(read)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","id":"1","payload":"`+text+`"}`)))
+	echo := readEnvelope(t, conn, "echo")
+	assert.Equal(t, `"`+text+`"`, string(echo.Payload))
+
+	return atomic.LoadInt64(read) - before
+}
+
+func TestWsCompression_Validate(t *testing.T) {
+	assert.NoError(t, controller.WsCompression{Level: -2}.Validate())
+	assert.NoError(t, controller.WsCompression{Level: 9}.Validate())
+	assert.EqualError(t, controller.WsCompression{Level: 10}.Validate(), "compression level 10 is outside of -2 to 9")
+	assert.Error(t, controller.WsCompression{Level: -3}.Validate())
+}
+
+func TestWs_CompressionNegotiation(t *testing.T) {
+	_, resp, _ := dialCounting(t, hub.New(), controller.WsCompression{Enabled: true})
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	_, resp, _ = dialCounting(t, hub.New(), controller.WsCompression{})
+	assert.Empty(t, resp.Header.Get("Sec-WebSocket-Extensions"))
+}
+
+func TestWs_CompressionThreshold(t *testing.T) {
+	text := strings.Repeat("hello ", 2000)
+
+	compressed := echoWireSize(t, controller.WsCompression{Enabled: true, Threshold: 256}, text)
+	assert.Less(t, compressed, int64(len(text)/10))
+
+	belowThreshold := echoWireSize(t, controller.WsCompression{Enabled: true, Threshold: 1 << 20}, text)
+	assert.Greater(t, belowThreshold, int64(len(text)), "Messages below the threshold should be sent uncompressed")
+}
+
+func BenchmarkWs_Broadcast(b *testing.B) {
+	text := strings.Repeat("Are we still meeting at the station at nine tomorrow? ", 10)
+	benchmarks := []struct {
+		name        string
+		compression controller.WsCompression
+	}{
+		{"uncompressed", controller.WsCompression{}},
+		{"deflate", controller.WsCompression{Enabled: true, Threshold: 256}},
+		{"deflate-best", controller.WsCompression{Enabled: true, Level: 9, Threshold: 256}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			h := hub.New()
+			conn, _, read := dialCounting(b, h, bm.compression)
+			if _, _, err := conn.ReadMessage(); err != nil {
+				b.Fatal(err)
+			}
+
+			before := atomic.LoadInt64(read)
+			b.SetBytes(int64(len(text)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.Publish(hub.EventMessage, "other", "", hub.MessageData{Text: text}); err != nil {
+					b.Fatal(err)
+				}
+				if _, _, err := conn.ReadMessage(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(atomic.LoadInt64(read)-before)/float64(b.N), "wire-B/op")
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package controller
+
+import (
+	"compress/flate"
+	"fmt"
+	"httpserver/internal/metrics"
+	"httpserver/internal/protocol"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWsPingInterval = 5 * time.Second
+	defaultWsPongWait     = 15 * time.Second
+	defaultWsWriteWait    = 15 * time.Second
+	defaultWsSessionTTL   = 2 * time.Minute
+	defaultWsAuthTimeout  = 5 * time.Second
+
+	defaultWsCompressionLevel = 1
+
+	wsReasonPongTimeout  = "pong timeout"
+	wsReasonIdleTimeout  = "idle timeout"
+	wsReasonSlowConsumer = "slow consumer"
+	wsReasonAuthTimeout  = "authentication timeout"
+	wsReasonAuthFailed   = "authentication failed"
+)
+
+// WsTimeouts configures dead connection detection, zero values fall back to the defaults except IdleTimeout, which
+// is disabled when zero.
+type WsTimeouts struct {
+	// PingInterval is how often the server pings and checks the deadlines below.
+	PingInterval time.Duration
+	// PongWait is the read deadline, the connection is dropped when nothing, not even a pong, arrives for this long.
+	PongWait time.Duration
+	// IdleTimeout closes connections that send no message for this long even though they answer pings.
+	IdleTimeout time.Duration
+	WriteWait   time.Duration
+	// SessionTTL is how long a session can be resumed after its connection is gone.
+	SessionTTL time.Duration
+	// AuthTimeout is how long a connection opened without credentials may take to send the auth envelope.
+	AuthTimeout time.Duration
+}
+
+func (t WsTimeouts) withDefaults() WsTimeouts {
+	if t.PingInterval <= 0 {
+		t.PingInterval = defaultWsPingInterval
+	}
+	if t.PongWait <= 0 {
+		t.PongWait = defaultWsPongWait
+	}
+	if t.WriteWait <= 0 {
+		t.WriteWait = defaultWsWriteWait
+	}
+	if t.SessionTTL <= 0 {
+		t.SessionTTL = defaultWsSessionTTL
+	}
+	if t.AuthTimeout <= 0 {
+		t.AuthTimeout = defaultWsAuthTimeout
+	}
+
+	return t
+}
+
+// WsCompression configures permessage-deflate, it is used only with clients that offer it.
+type WsCompression struct {
+	Enabled bool
+	// Level is a compress/flate level from -2 to 9, zero means 1, the fastest.
+	Level int
+	// Threshold is the size in bytes below which messages are sent uncompressed, compressing them costs more than it
+	// saves.
+	Threshold int
+}
+
+// Validate rejects a level compress/flate does not support, the connection would silently keep its default level.
+func (c WsCompression) Validate() error {
+	if c.Level < flate.HuffmanOnly || c.Level > flate.BestCompression {
+		return fmt.Errorf("compression level %d is outside of %d to %d", c.Level, flate.HuffmanOnly, flate.BestCompression)
+	}
+
+	return nil
+}
+
+func (c WsCompression) withDefaults() WsCompression {
+	if c.Level == 0 {
+		c.Level = defaultWsCompressionLevel
+	}
+
+	return c
+}
+
+// wsConnection serializes writes, gorilla/websocket supports a single concurrent writer only. Activity is tracked
+// with the controller clock so that deadlines can be tested without waiting.
+type wsConnection struct {
+	conn        *websocket.Conn
+	clock       clock.Clock
+	timeouts    WsTimeouts
+	compression WsCompression
+	codec       protocol.Codec
+
+	mu     sync.Mutex
+	closed bool
+
+	activity    sync.Mutex
+	lastRead    time.Time
+	lastMessage time.Time
+}
+
+func newWsConnection(conn *websocket.Conn, clock clock.Clock, timeouts WsTimeouts, compression WsCompression, codec protocol.Codec) *wsConnection {
+	now := clock.Now()
+	connection := &wsConnection{
+		conn:        conn,
+		clock:       clock,
+		timeouts:    timeouts,
+		compression: compression,
+		codec:       codec,
+		lastRead:    now,
+		lastMessage: now,
+	}
+	if compression.Enabled {
+		// The level was checked by Validate on startup.
+		conn.SetCompressionLevel(compression.Level)
+	}
+	conn.SetPongHandler(func(string) error {
+		connection.touch(false)
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		connection.touch(false)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(timeouts.WriteWait))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	return connection
+}
+
+// read returns the next data message, text or binary, and counts it as activity.
+func (c *wsConnection) read() ([]byte, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	c.touch(true)
+
+	return data, nil
+}
+
+func (c *wsConnection) touch(message bool) {
+	c.activity.Lock()
+	defer c.activity.Unlock()
+
+	c.lastRead = c.clock.Now()
+	if message {
+		c.lastMessage = c.lastRead
+	}
+}
+
+// expired returns why the connection should be dropped, or an empty string while it is alive.
+func (c *wsConnection) expired() string {
+	c.activity.Lock()
+	defer c.activity.Unlock()
+
+	now := c.clock.Now()
+	switch {
+	case now.Sub(c.lastRead) >= c.timeouts.PongWait:
+		return wsReasonPongTimeout
+	case c.timeouts.IdleTimeout > 0 && now.Sub(c.lastMessage) >= c.timeouts.IdleTimeout:
+		return wsReasonIdleTimeout
+	}
+
+	return ""
+}
+
+func (c *wsConnection) send(envelope protocol.Envelope) error {
+	data, err := c.codec.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	messageType := websocket.TextMessage
+	if c.codec.Binary() {
+		messageType = websocket.BinaryMessage
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errConnectionClosed
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeouts.WriteWait))
+	c.conn.EnableWriteCompression(c.compression.Enabled && len(data) >= c.compression.Threshold)
+
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *wsConnection) reply(logger *zap.SugaredLogger, envelope protocol.Envelope) {
+	if err := c.send(envelope); err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	metrics.WebsocketMessagesTotal.WithLabelValues(metrics.DirectionOut, envelope.Type).Inc()
+}
+
+// keepAlive pings on every tick and closes the connection once a deadline passes, the ticker is created by the
+// caller so that no tick is missed before the goroutine starts.
+func (c *wsConnection) keepAlive(ticker *clock.Ticker, logger *zap.SugaredLogger, done <-chan struct{}) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if reason := c.expired(); reason != "" {
+				logger.Infow("closing websocket connection", "reason", reason)
+				metrics.WebsocketDisconnectsTotal.WithLabelValues(reason).Inc()
+				c.close(websocket.CloseGoingAway, reason)
+				return
+			}
+			if err := c.ping(); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConnection) ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errConnectionClosed
+	}
+
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.timeouts.WriteWait))
+}
+
+func (c *wsConnection) Close() error {
+	return c.close(websocket.CloseNormalClosure, "")
+}
+
+func (c *wsConnection) close(code int, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(c.timeouts.WriteWait))
+
+	return c.conn.Close()
+}
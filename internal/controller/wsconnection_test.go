@@ -0,0 +1,157 @@
+package controller_test
+
+import (
+	"context"
+	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/protocol"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type releasingActiveUsersStorage struct {
+	mockActiveUsersStorage
+	deleted chan string
+}
+
+func (m *releasingActiveUsersStorage) Delete(ctx context.Context, userID string) {
+	m.deleted <- userID
+}
+
+func newClockedWsServer(t *testing.T, configure func(*controller.Controller)) (*httptest.Server, *clock.Mock, *releasingActiveUsersStorage) {
+	logger := zaptest.NewLogger(t).Sugar()
+	mock := clock.NewMock()
+	activeUsersStorage := &releasingActiveUsersStorage{deleted: make(chan string, 1)}
+	ctrl := &controller.Controller{
+		TokenStorage:       &mockTokenStorage{},
+		ActiveUsersStorage: activeUsersStorage,
+		UserStorage:        &mockUserStorage{},
+		Hub:                hub.New(),
+		Clock:              mock,
+	}
+	configure(ctrl)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), logger)))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, mock, activeUsersStorage
+}
+
+func TestWs_DeadPeerIsDisconnectedAfterPongWait(t *testing.T) {
+	server, mock, activeUsersStorage := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsTimeouts = controller.WsTimeouts{PingInterval: time.Second, PongWait: 3 * time.Second}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	// The client stops reading, so the pings of the server are never answered.
+	var released string
+	assert.Eventually(t, func() bool {
+		select {
+		case released = <-activeUsersStorage.deleted:
+			return true
+		default:
+			mock.Add(time.Second)
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "1", released)
+}
+
+func TestWs_IdleConnectionIsClosed(t *testing.T) {
+	server, mock, activeUsersStorage := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsTimeouts = controller.WsTimeouts{PingInterval: time.Second, PongWait: time.Hour, IdleTimeout: 3 * time.Second}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	// Reading answers the pings, the connection is alive but sends no message.
+	closed := make(chan error, 1)
+	go func() {
+		conn.SetReadDeadline(time.Time{})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}()
+
+	var readErr error
+	assert.Eventually(t, func() bool {
+		select {
+		case readErr = <-closed:
+			return true
+		default:
+			mock.Add(time.Second)
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, readErr, &closeErr)
+	assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	assert.Equal(t, "idle timeout", closeErr.Text)
+
+	select {
+	case userID := <-activeUsersStorage.deleted:
+		assert.Equal(t, "1", userID)
+	case <-time.After(time.Second):
+		t.Fatal("active user was not released")
+	}
+}
+
+func TestWs_SlowConsumerIsDisconnected(t *testing.T) {
+	logger := zaptest.NewLogger(t).Sugar()
+	h := hub.New(hub.WithSendBuffer(1, hub.Disconnect))
+	ctrl := &controller.Controller{
+		TokenStorage:       &mockTokenStorage{},
+		ActiveUsersStorage: &mockActiveUsersStorage{},
+		UserStorage:        &mockUserStorage{},
+		Hub:                h,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctrl.Ws(w, r.WithContext(logging.NewContext(r.Context(), logger)))
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	// Publishing is faster than writing every event to the socket, so the buffer of one event overflows.
+	for i := 0; i < 1000; i++ {
+		_, err := h.Publish(hub.EventMessage, "other", "", hub.MessageData{Text: "hello"})
+		require.NoError(t, err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, websocket.CloseTryAgainLater, closeErr.Code)
+		assert.Equal(t, "slow consumer", closeErr.Text)
+		return
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+	"httpserver/internal/ratelimit"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	defaultWsMaxMessageSize = 64 << 10
+	defaultWsWarnings       = 3
+	defaultWsMuteDuration   = 30 * time.Second
+	defaultWsMutes          = 2
+	defaultWsQuietPeriod    = 5 * time.Minute
+
+	wsReasonMessageTooBig   = "message too big"
+	wsReasonPolicyViolation = "policy violation"
+
+	wsActionWarn       = "warn"
+	wsActionMute       = "mute"
+	wsActionDrop       = "drop"
+	wsActionDisconnect = "disconnect"
+)
+
+var (
+	defaultWsRate = ratelimit.Rate{PerSecond: 10, Burst: 20}
+	// Acks follow the events the server sends rather than what the client does, so they get a rate of their own.
+	defaultWsAckRate = ratelimit.Rate{PerSecond: 100, Burst: 200}
+)
+
+// WsLimits bounds what a client may send on one connection, zero values fall back to the defaults. Envelopes over
+// the rate of their type are answered with warnings first, then the connection is muted, then closed. A connection
+// that stays under its rates for the quiet period starts over with warnings.
+type WsLimits struct {
+	// MaxMessageSize closes the connection with the message too big code when a frame is larger.
+	MaxMessageSize int64
+	// Rates limits inbound envelopes by type, ratelimit.DefaultKey applies to types without a rate of their own.
+	Rates map[string]ratelimit.Rate
+	// Warnings is how many envelopes over the rate are answered with a warning event before muting.
+	Warnings int
+	// MuteDuration is how long every envelope of a muted connection is dropped.
+	MuteDuration time.Duration
+	// Mutes is how many times a connection is muted before the next violation closes it.
+	Mutes int
+	// QuietPeriod is how long after its last warning or mute a connection is forgiven its violations.
+	QuietPeriod time.Duration
+}
+
+func (l WsLimits) withDefaults() WsLimits {
+	if l.MaxMessageSize <= 0 {
+		l.MaxMessageSize = defaultWsMaxMessageSize
+	}
+	rates := map[string]ratelimit.Rate{ratelimit.DefaultKey: defaultWsRate, EventAck: defaultWsAckRate}
+	for eventType, rate := range l.Rates {
+		rates[eventType] = rate
+	}
+	l.Rates = rates
+	if l.Warnings <= 0 {
+		l.Warnings = defaultWsWarnings
+	}
+	if l.MuteDuration <= 0 {
+		l.MuteDuration = defaultWsMuteDuration
+	}
+	if l.Mutes <= 0 {
+		l.Mutes = defaultWsMutes
+	}
+	if l.QuietPeriod <= 0 {
+		l.QuietPeriod = defaultWsQuietPeriod
+	}
+
+	return l
+}
+
+// wsLimiter keeps the token buckets and escalation state of one connection, it is used by the read loop only.
+type wsLimiter struct {
+	limits     WsLimits
+	clock      clock.Clock
+	buckets    map[string]*ratelimit.Bucket
+	violations int
+	mutes      int
+	mutedUntil time.Time
+	// quietSince is when the last warning was sent or the last mute ended.
+	quietSince time.Time
+}
+
+func newWsLimiter(limits WsLimits, clock clock.Clock) *wsLimiter {
+	return &wsLimiter{limits: limits, clock: clock, buckets: map[string]*ratelimit.Bucket{}}
+}
+
+// check returns an empty action when the envelope may be handled, otherwise how the connection is punished.
+func (l *wsLimiter) check(eventType string) string {
+	now := l.clock.Now()
+	if now.Before(l.mutedUntil) {
+		return wsActionDrop
+	}
+
+	bucket, ok := l.buckets[eventType]
+	if !ok {
+		rate, ok := l.limits.Rates[eventType]
+		if !ok {
+			rate = l.limits.Rates[ratelimit.DefaultKey]
+		}
+		bucket = ratelimit.NewBucket(rate, now)
+		l.buckets[eventType] = bucket
+	}
+	if bucket.Allow(now) {
+		return ""
+	}
+
+	if l.violations > 0 && now.Sub(l.quietSince) >= l.limits.QuietPeriod {
+		l.violations, l.mutes = 0, 0
+	}
+	l.violations++
+	switch {
+	case l.violations <= l.limits.Warnings:
+		l.quietSince = now
+		return wsActionWarn
+	case l.mutes < l.limits.Mutes:
+		l.mutes++
+		l.mutedUntil = now.Add(l.limits.MuteDuration)
+		l.quietSince = l.mutedUntil
+		return wsActionMute
+	}
+
+	return wsActionDisconnect
+}
+
+func (l *wsLimiter) muteMessage() string {
+	return fmt.Sprintf("muted for %s, envelopes sent until then are dropped", l.limits.MuteDuration)
+}
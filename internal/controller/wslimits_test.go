@@ -0,0 +1,157 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/protocol"
+	"httpserver/internal/ratelimit"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireClosed(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, code, closeErr.Code)
+		return
+	}
+}
+
+func TestWs_RateLimitEscalation(t *testing.T) {
+	server, mock, _ := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsLimits = controller.WsLimits{
+			Rates:        map[string]ratelimit.Rate{ratelimit.DefaultKey: {PerSecond: 1, Burst: 1}},
+			Warnings:     1,
+			MuteDuration: 10 * time.Second,
+			Mutes:        1,
+		}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	echo := func(id string) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","id":"`+id+`","payload":"hi"}`)))
+	}
+	warning := func(code string, id string) {
+		envelope := readEnvelope(t, conn, protocol.EventWarning)
+		var payload protocol.Error
+		require.NoError(t, json.Unmarshal(envelope.Payload, &payload))
+		assert.Equal(t, code, payload.Code)
+		assert.Equal(t, id, envelope.CorrelationID)
+	}
+
+	echo("1")
+	assert.Equal(t, "1", readEnvelope(t, conn, "echo").CorrelationID)
+	echo("2")
+	warning(protocol.CodeRateLimited, "2")
+	echo("3")
+	warning(protocol.CodeMuted, "3")
+	echo("4")
+
+	mock.Add(11 * time.Second)
+	echo("5")
+	assert.Equal(t, "5", readEnvelope(t, conn, "echo").CorrelationID, "Envelopes sent while muted should be dropped")
+
+	echo("6")
+	requireClosed(t, conn, websocket.ClosePolicyViolation)
+}
+
+func TestWs_MessageTooBig(t *testing.T) {
+	server, _, _ := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsLimits = controller.WsLimits{MaxMessageSize: 64}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	payload := `{"type":"echo","id":"1","payload":"` + strings.Repeat("a", 100) + `"}`
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(payload)))
+	requireClosed(t, conn, websocket.CloseMessageTooBig)
+}
+
+func TestWs_RateLimitViolationsDecay(t *testing.T) {
+	server, mock, _ := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsLimits = controller.WsLimits{
+			Rates:        map[string]ratelimit.Rate{ratelimit.DefaultKey: {PerSecond: 1, Burst: 1}},
+			Warnings:     1,
+			MuteDuration: 10 * time.Second,
+			Mutes:        1,
+			QuietPeriod:  time.Minute,
+		}
+		// The clock moves past the default pong wait, which would close the connection.
+		ctrl.WsTimeouts = controller.WsTimeouts{PingInterval: time.Hour, PongWait: 2 * time.Hour}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	echo := func(id string) {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","id":"`+id+`","payload":"hi"}`)))
+	}
+
+	echo("1")
+	echo("2")
+	echo("3")
+	readEnvelope(t, conn, protocol.EventWarning)
+	assert.Equal(t, "3", readEnvelope(t, conn, protocol.EventWarning).CorrelationID)
+
+	mock.Add(10*time.Second + time.Minute)
+	echo("4")
+	assert.Equal(t, "4", readEnvelope(t, conn, "echo").CorrelationID)
+	echo("5")
+	envelope := readEnvelope(t, conn, protocol.EventWarning)
+	var payload protocol.Error
+	require.NoError(t, json.Unmarshal(envelope.Payload, &payload))
+	assert.Equal(t, protocol.CodeRateLimited, payload.Code, "A quiet connection should be warned again rather than closed")
+}
+
+func TestWs_AcksHaveTheirOwnRate(t *testing.T) {
+	server, _, _ := newClockedWsServer(t, func(ctrl *controller.Controller) {
+		ctrl.WsLimits = controller.WsLimits{
+			Rates:    map[string]ratelimit.Rate{ratelimit.DefaultKey: {PerSecond: 1, Burst: 1}},
+			Warnings: 1,
+			Mutes:    1,
+		}
+	})
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/ws?token=valid_token", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	readEnvelope(t, conn, protocol.EventWelcome)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ack","payload":{"id":"1"}}`)))
+	}
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"echo","id":"1","payload":"hi"}`)))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var envelope protocol.Envelope
+		require.NoError(t, conn.ReadJSON(&envelope))
+		if envelope.Type != hub.EventPresence {
+			assert.Equal(t, "echo", envelope.Type, "Acks should not count against the default rate")
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	allowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	allowedHeaders = []string{"Authorization", "Content-Type", "Last-Event-ID"}
+	exposedHeaders = []string{"Deprecation", "Link", "Sunset", "WWW-Authenticate", "X-Expires-After", "X-Rate-Limit"}
+
+	preflightMaxAge = 10 * time.Minute
+)
+
+// Origins lists the origins, such as https://app.example.com, allowed to call the API from a browser. * allows any
+// origin.
+type Origins []string
+
+func (o Origins) Allow(origin string) bool {
+	for _, allowed := range o {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckOrigin guards WebSocket upgrades against cross-site hijacking. Requests without an Origin header do not come
+// from a browser and requests from the origin serving the API are always allowed.
+func (o Origins) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	return o.Allow(origin)
+}
+
+// Middleware adds CORS headers for allowed origins and answers preflight requests. Bearer tokens are sent in the
+// Authorization header, so credentials are not allowed.
+func Middleware(origins Origins) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !origins.Allow(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if !preflight {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(preflightMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package cors_test
+
+import (
+	"httpserver/internal/cors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(origins cors.Origins, r *http.Request) *httptest.ResponseRecorder {
+	handler := cors.Middleware(origins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestMiddleware_AllowedOrigin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/user/login", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+
+	w := serve(cors.Origins{"https://app.example.com"}, r)
+
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Rate-Limit")
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+}
+
+func TestMiddleware_DisallowedOrigin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/user/login", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+
+	w := serve(cors.Origins{"https://app.example.com"}, r)
+
+	assert.Equal(t, http.StatusTeapot, w.Code, "The browser blocks the response, the server still handles the request")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestMiddleware_WithoutOrigin(t *testing.T) {
+	w := serve(cors.Origins{"*"}, httptest.NewRequest(http.MethodGet, "/api/v1/user/active", nil))
+
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Values("Vary"))
+}
+
+func TestMiddleware_Preflight(t *testing.T) {
+	tests := []struct {
+		origins cors.Origins
+		status  int
+		allowed string
+	}{
+		{cors.Origins{"https://app.example.com"}, http.StatusNoContent, "https://app.example.com"},
+		{cors.Origins{"*"}, http.StatusNoContent, "https://app.example.com"},
+		{cors.Origins{"https://other.example.com"}, http.StatusForbidden, ""},
+		{nil, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodOptions, "/api/v1/user/login", nil)
+		r.Header.Set("Origin", "https://app.example.com")
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		r.Header.Set("Access-Control-Request-Headers", "content-type")
+
+		w := serve(tt.origins, r)
+
+		assert.Equal(t, tt.status, w.Code, tt.origins)
+		assert.Equal(t, tt.allowed, w.Header().Get("Access-Control-Allow-Origin"), tt.origins)
+		if tt.status == http.StatusNoContent {
+			assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+			assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+			assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		}
+	}
+}
+
+func TestOrigins_CheckOrigin(t *testing.T) {
+	origins := cors.Origins{"https://app.example.com"}
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://api.example.com", true},
+		{"https://APP.example.com", true},
+		{"https://evil.example.com", false},
+		{"null", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		assert.Equal(t, tt.allowed, origins.CheckOrigin(r), tt.origin)
+	}
+}
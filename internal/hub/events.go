@@ -0,0 +1,142 @@
+package hub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventMessage  = "message"
+	EventPresence = "presence"
+
+	PresenceOnline  = "online"
+	PresenceOffline = "offline"
+)
+
+// Event is delivered to every subscriber when To is empty, otherwise only to the To and From users.
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+	From string          `json:"-"`
+	To   string          `json:"-"`
+}
+
+func (e Event) visibleTo(userID string) bool {
+	return e.To == "" || e.To == userID || e.From == userID
+}
+
+type MessageData struct {
+	From     string `json:"from"`
+	FromName string `json:"fromName"`
+	To       string `json:"to,omitempty"`
+	Text     string `json:"text"`
+}
+
+type PresenceData struct {
+	UserID   string `json:"userId"`
+	UserName string `json:"userName"`
+	Status   string `json:"status"`
+}
+
+type Subscription struct {
+	hub    *Hub
+	userID string
+	policy OverflowPolicy
+	events chan Event
+	err    error
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns ErrSlowConsumer when the hub closed the subscription because its buffer was full.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Close stops delivery and closes the events channel, it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+func (h *Hub) Publish(eventType string, from string, to string, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{Type: eventType, Time: h.clock.Now().UTC(), Data: payload, From: from, To: to}
+	if h.pubsub != nil {
+		return h.relay(event)
+	}
+
+	h.mu.Lock()
+	event = h.append(event)
+	h.mu.Unlock()
+
+	return event, nil
+}
+
+// append retains the event and delivers it to the subscribers, an event without an id gets the one after the last
+// event. It must be called with the hub locked.
+func (h *Hub) append(event Event) Event {
+	if event.ID == 0 {
+		event.ID = h.lastEventID + 1
+	}
+	h.lastEventID = event.ID
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for subscription := range h.subscribers {
+		if event.visibleTo(subscription.userID) {
+			h.deliver(subscription, event)
+		}
+	}
+
+	return event
+}
+
+// Subscribe replays retained events newer than lastEventID before delivering live ones.
+func (h *Hub) Subscribe(userID string, lastEventID uint64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.subscribe(userID, lastEventID, h.overflowPolicy)
+}
+
+func (h *Hub) subscribe(userID string, lastEventID uint64, policy OverflowPolicy) *Subscription {
+	var replay []Event
+	if lastEventID > 0 {
+		for _, event := range h.history {
+			if event.ID > lastEventID && event.visibleTo(userID) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	subscription := &Subscription{hub: h, userID: userID, policy: policy, events: make(chan Event, len(replay)+h.sendBuffer)}
+	for _, event := range replay {
+		subscription.events <- event
+	}
+	h.subscribers[subscription] = struct{}{}
+
+	return subscription
+}
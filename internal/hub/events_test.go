@@ -0,0 +1,92 @@
+package hub_test
+
+import (
+	"httpserver/internal/hub"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, subscription *hub.Subscription) []hub.Event {
+	t.Helper()
+
+	var events []hub.Event
+	for {
+		select {
+		case event := <-subscription.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestHub_PublishBroadcast(t *testing.T) {
+	h := hub.New()
+	first := h.Subscribe("user-1", 0)
+	second := h.Subscribe("user-2", 0)
+
+	event, err := h.Publish(hub.EventMessage, "user-1", "", hub.MessageData{From: "user-1", Text: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), event.ID)
+	assert.JSONEq(t, `{"from":"user-1","fromName":"","text":"hello"}`, string(event.Data))
+
+	assert.Equal(t, []hub.Event{event}, receive(t, first))
+	assert.Equal(t, []hub.Event{event}, receive(t, second))
+}
+
+func TestHub_PublishDirect(t *testing.T) {
+	h := hub.New()
+	sender := h.Subscribe("user-1", 0)
+	recipient := h.Subscribe("user-2", 0)
+	other := h.Subscribe("user-3", 0)
+
+	event, err := h.Publish(hub.EventMessage, "user-1", "user-2", hub.MessageData{Text: "hello"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []hub.Event{event}, receive(t, sender))
+	assert.Equal(t, []hub.Event{event}, receive(t, recipient))
+	assert.Empty(t, receive(t, other))
+}
+
+func TestHub_SubscribeReplay(t *testing.T) {
+	h := hub.New()
+	var events []hub.Event
+	for i := 0; i < 3; i++ {
+		event, err := h.Publish(hub.EventMessage, "user-1", "", hub.MessageData{Text: "hello"})
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+	_, err := h.Publish(hub.EventMessage, "user-1", "user-2", hub.MessageData{Text: "private"})
+	require.NoError(t, err)
+
+	assert.Empty(t, receive(t, h.Subscribe("user-3", 0)))
+	assert.Equal(t, events[1:], receive(t, h.Subscribe("user-3", 1)))
+}
+
+func TestHub_HistorySize(t *testing.T) {
+	h := hub.New(hub.WithHistorySize(2))
+	var events []hub.Event
+	for i := 0; i < 4; i++ {
+		event, err := h.Publish(hub.EventPresence, "user-1", "", hub.PresenceData{Status: hub.PresenceOnline})
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+
+	assert.Equal(t, events[2:], receive(t, h.Subscribe("user-2", events[0].ID)))
+}
+
+func TestSubscription_Close(t *testing.T) {
+	h := hub.New()
+	subscription := h.Subscribe("user-1", 0)
+
+	subscription.Close()
+	subscription.Close()
+
+	_, ok := <-subscription.Events()
+	assert.False(t, ok)
+
+	_, err := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "hello"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,151 @@
+package hub
+
+import (
+	"httpserver/internal/pubsub"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const defaultHistorySize = 1024
+
+type Hub struct {
+	mu          sync.Mutex
+	connections map[string]map[io.Closer]struct{}
+	subscribers map[*Subscription]struct{}
+	pollQueues  map[string]*PollQueue
+	sessions    map[string]*Session
+	history     []Event
+	historySize int
+	lastEventID uint64
+	clock       clock.Clock
+
+	sendBuffer     int
+	overflowPolicy OverflowPolicy
+	onDrop         DropHandler
+
+	pubsub       pubsub.PubSub
+	node         string
+	stopRelay    func()
+	onRelayError RelayErrorHandler
+	onRemote     RemoteHandler
+
+	nodes            map[string]*node
+	presenceInterval time.Duration
+	stopHeartbeat    chan struct{}
+	heartbeatDone    chan struct{}
+}
+
+type Option func(*Hub)
+
+// WithClock sets the clock of the session expiry, the poll timers, the presence heartbeat and the event times.
+func WithClock(clock clock.Clock) Option {
+	return func(h *Hub) {
+		if clock != nil {
+			h.clock = clock
+		}
+	}
+}
+
+// WithHistorySize sets how many recent events are kept for resuming subscribers.
+func WithHistorySize(size int) Option {
+	return func(h *Hub) {
+		h.historySize = size
+	}
+}
+
+func New(options ...Option) *Hub {
+	h := &Hub{
+		connections: map[string]map[io.Closer]struct{}{},
+		subscribers: map[*Subscription]struct{}{},
+		pollQueues:  map[string]*PollQueue{},
+		sessions:    map[string]*Session{},
+		historySize: defaultHistorySize,
+		clock:       clock.New(),
+
+		sendBuffer:     defaultSendBuffer,
+		overflowPolicy: defaultOverflowPolicy,
+
+		nodes:            map[string]*node{},
+		presenceInterval: defaultPresenceInterval,
+	}
+
+	for _, option := range options {
+		option(h)
+	}
+	h.joinRelay()
+
+	return h
+}
+
+// Register adds a connection of the user and returns how many the user has now, counted under the same lock so
+// only one of two racing connections sees the first.
+func (h *Hub) Register(userID string, conn io.Closer) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.connections[userID] == nil {
+		h.connections[userID] = map[io.Closer]struct{}{}
+	}
+	h.connections[userID][conn] = struct{}{}
+
+	return len(h.connections[userID])
+}
+
+// Unregister removes a connection of the user and returns how many the user has left.
+func (h *Hub) Unregister(userID string, conn io.Closer) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.connections[userID], conn)
+	left := len(h.connections[userID])
+	if left == 0 {
+		delete(h.connections, userID)
+		h.leaveNode(userID)
+	}
+
+	return left
+}
+
+func (h *Hub) Count(userID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.connections[userID])
+}
+
+// Disconnect closes the connections of the user and drops its sessions so they cannot be resumed, on this instance and
+// on the others. It returns how many connections of this instance were closed.
+func (h *Hub) Disconnect(userID string) int {
+	disconnected := h.disconnect(userID)
+	if h.pubsub != nil {
+		h.send(relayedEvent{Node: h.node, Kind: relayDisconnect, UserID: userID})
+	}
+
+	return disconnected
+}
+
+func (h *Hub) disconnect(userID string) int {
+	h.mu.Lock()
+	connections := h.connections[userID]
+	delete(h.connections, userID)
+	h.leaveNode(userID)
+	var sessions []*Session
+	for _, session := range h.sessions {
+		if session.userID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	h.mu.Unlock()
+
+	for conn := range connections {
+		conn.Close()
+	}
+	for _, session := range sessions {
+		session.Close()
+	}
+
+	return len(connections)
+}
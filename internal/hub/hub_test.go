@@ -0,0 +1,81 @@
+package hub_test
+
+import (
+	"httpserver/internal/hub"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConn struct {
+	closed bool
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestHub_RegisterAndUnregister(t *testing.T) {
+	h := hub.New()
+	conn := &fakeConn{}
+
+	assert.Equal(t, 1, h.Register("user-1", conn))
+	assert.Equal(t, 1, h.Count("user-1"))
+
+	assert.Equal(t, 0, h.Unregister("user-1", conn))
+	assert.Equal(t, 0, h.Count("user-1"))
+	assert.False(t, conn.closed)
+}
+
+func TestHub_RegisterReturnsTheCount(t *testing.T) {
+	h := hub.New()
+	first, second := &fakeConn{}, &fakeConn{}
+
+	assert.Equal(t, 1, h.Register("user-1", first))
+	assert.Equal(t, 2, h.Register("user-1", second))
+	assert.Equal(t, 1, h.Unregister("user-1", first))
+	assert.Equal(t, 0, h.Unregister("user-1", second))
+}
+
+func TestHub_ConcurrentRegisterSeesOneFirst(t *testing.T) {
+	h := hub.New()
+	var firsts int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if h.Register("user-1", &fakeConn{}) == 1 {
+				atomic.AddInt32(&firsts, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), firsts)
+}
+
+func TestHub_Disconnect(t *testing.T) {
+	h := hub.New()
+	first, second, other := &fakeConn{}, &fakeConn{}, &fakeConn{}
+
+	h.Register("user-1", first)
+	h.Register("user-1", second)
+	h.Register("user-2", other)
+
+	assert.Equal(t, 2, h.Disconnect("user-1"))
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+	assert.False(t, other.closed)
+	assert.Equal(t, 0, h.Count("user-1"))
+	assert.Equal(t, 1, h.Count("user-2"))
+}
+
+func TestHub_DisconnectUnknownUser(t *testing.T) {
+	h := hub.New()
+
+	assert.Equal(t, 0, h.Disconnect("user-1"))
+}
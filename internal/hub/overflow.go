@@ -0,0 +1,86 @@
+package hub
+
+import (
+	"errors"
+	"fmt"
+)
+
+// OverflowPolicy decides what happens to an event published while the buffer of a subscriber is full.
+type OverflowPolicy string
+
+const (
+	// DropNewest keeps the buffered events and drops the published one.
+	DropNewest OverflowPolicy = "drop_newest"
+	// DropOldest drops the oldest buffered event to make room for the published one.
+	DropOldest OverflowPolicy = "drop_oldest"
+	// Disconnect closes the subscription, Err returns ErrSlowConsumer once its events are drained.
+	Disconnect OverflowPolicy = "disconnect"
+
+	defaultSendBuffer     = 64
+	defaultOverflowPolicy = DropNewest
+)
+
+var ErrSlowConsumer = errors.New("subscriber is too slow to receive events")
+
+func ParseOverflowPolicy(value string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(value); policy {
+	case DropNewest, DropOldest, Disconnect:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown overflow policy %q", value)
+}
+
+// DropHandler is called with the hub locked for every event a subscriber misses, it must not call the hub.
+type DropHandler func(userID string, event Event, policy OverflowPolicy)
+
+// WithSendBuffer sets how many events may wait for each subscriber, session and poll queue and what happens when they
+// do not fit. Only Disconnect lets the clients resume from the last event they received instead of missing some.
+func WithSendBuffer(size int, policy OverflowPolicy) Option {
+	return func(h *Hub) {
+		if size > 0 {
+			h.sendBuffer = size
+		}
+		if policy != "" {
+			h.overflowPolicy = policy
+		}
+	}
+}
+
+func WithDropHandler(handler DropHandler) Option {
+	return func(h *Hub) {
+		h.onDrop = handler
+	}
+}
+
+// deliver must be called with the hub locked.
+func (h *Hub) deliver(subscription *Subscription, event Event) {
+	select {
+	case subscription.events <- event:
+		return
+	default:
+	}
+
+	switch subscription.policy {
+	case DropOldest:
+		// Only the publisher sends while holding the lock, so receiving one event always makes room.
+		select {
+		case dropped := <-subscription.events:
+			h.dropped(subscription, dropped)
+		default:
+		}
+		subscription.events <- event
+	case Disconnect:
+		h.dropped(subscription, event)
+		subscription.err = ErrSlowConsumer
+		h.unsubscribe(subscription)
+	default:
+		h.dropped(subscription, event)
+	}
+}
+
+func (h *Hub) dropped(subscription *Subscription, event Event) {
+	if h.onDrop != nil {
+		h.onDrop(subscription.userID, event, subscription.policy)
+	}
+}
@@ -0,0 +1,160 @@
+package hub_test
+
+import (
+	"context"
+	"httpserver/internal/hub"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type drop struct {
+	userID string
+	id     uint64
+	policy hub.OverflowPolicy
+}
+
+func newOverflowHub(policy hub.OverflowPolicy) (*hub.Hub, *[]drop) {
+	var drops []drop
+	h := hub.New(
+		hub.WithSendBuffer(2, policy),
+		hub.WithDropHandler(func(userID string, event hub.Event, policy hub.OverflowPolicy) {
+			drops = append(drops, drop{userID: userID, id: event.ID, policy: policy})
+		}),
+	)
+
+	return h, &drops
+}
+
+func publishN(t *testing.T, h *hub.Hub, n int) []hub.Event {
+	t.Helper()
+
+	var events []hub.Event
+	for i := 0; i < n; i++ {
+		event, err := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "hello"})
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestHub_OverflowDropNewest(t *testing.T) {
+	h, drops := newOverflowHub(hub.DropNewest)
+	slow := h.Subscribe("user-1", 0)
+
+	events := publishN(t, h, 3)
+
+	assert.Equal(t, events[:2], receive(t, slow))
+	assert.Equal(t, []drop{{userID: "user-1", id: events[2].ID, policy: hub.DropNewest}}, *drops)
+	assert.NoError(t, slow.Err())
+}
+
+func TestHub_OverflowDropOldest(t *testing.T) {
+	h, drops := newOverflowHub(hub.DropOldest)
+	slow := h.Subscribe("user-1", 0)
+
+	events := publishN(t, h, 3)
+
+	assert.Equal(t, events[1:], receive(t, slow))
+	assert.Equal(t, []drop{{userID: "user-1", id: events[0].ID, policy: hub.DropOldest}}, *drops)
+	assert.NoError(t, slow.Err())
+}
+
+func TestHub_OverflowDisconnect(t *testing.T) {
+	h, drops := newOverflowHub(hub.Disconnect)
+	slow := h.Subscribe("user-1", 0)
+	fast := h.Subscribe("user-2", 0)
+
+	events := publishN(t, h, 2)
+	assert.Equal(t, events, receive(t, fast))
+	events = append(events, publishN(t, h, 1)...)
+
+	var received []hub.Event
+	for event := range slow.Events() {
+		received = append(received, event)
+	}
+	assert.Equal(t, events[:2], received, "Buffered events should be delivered before the channel is closed")
+	assert.ErrorIs(t, slow.Err(), hub.ErrSlowConsumer)
+	assert.Equal(t, []drop{{userID: "user-1", id: events[2].ID, policy: hub.Disconnect}}, *drops)
+
+	assert.Equal(t, events[2:], receive(t, fast), "Other subscribers should keep receiving events")
+	slow.Close()
+}
+
+func TestPollQueue_SlowConsumerClosesQueue(t *testing.T) {
+	h, _ := newOverflowHub(hub.Disconnect)
+	closed := false
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, func() { closed = true })
+
+	publishN(t, h, 3)
+
+	_, err := queue.Poll(context.Background(), 0, time.Second)
+	assert.ErrorIs(t, err, hub.ErrSlowConsumer)
+	assert.True(t, closed)
+	_, ok := h.PollQueue("token")
+	assert.False(t, ok)
+}
+
+func TestSession_OverflowDisconnectResumes(t *testing.T) {
+	h, _ := newOverflowHub(hub.Disconnect)
+	session := h.OpenSession("token", "user-1", time.Minute)
+	subscription, _, err := session.Attach(&fakeConn{})
+	require.NoError(t, err)
+
+	events := publishN(t, h, 3)
+
+	var received []hub.Event
+	for event := range subscription.Events() {
+		session.Sent(event)
+		received = append(received, event)
+	}
+	assert.Equal(t, events[:2], received)
+	assert.ErrorIs(t, subscription.Err(), hub.ErrSlowConsumer)
+
+	subscription, _, err = session.Attach(&fakeConn{})
+	require.NoError(t, err)
+	assert.Equal(t, events[2:], receive(t, subscription), "The event that did not fit should be replayed on resume")
+}
+
+func TestSession_OverflowFollowsThePolicy(t *testing.T) {
+	h, drops := newOverflowHub(hub.DropNewest)
+	session := h.OpenSession("token", "user-1", time.Minute)
+	subscription, _, err := session.Attach(&fakeConn{})
+	require.NoError(t, err)
+
+	events := publishN(t, h, 3)
+
+	assert.Equal(t, events[:2], receive(t, subscription))
+	assert.Equal(t, []drop{{userID: "user-1", id: events[2].ID, policy: hub.DropNewest}}, *drops)
+	assert.NoError(t, subscription.Err())
+}
+
+func TestPollQueue_PendingEventsAreLimited(t *testing.T) {
+	h, _ := newOverflowHub(hub.DropNewest)
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+
+	events := publishN(t, h, 2)
+	polled, err := queue.Poll(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, events, polled)
+
+	publishN(t, h, 1)
+	_, err = queue.Poll(context.Background(), 0, time.Second)
+	assert.ErrorIs(t, err, hub.ErrSlowConsumer, "Unacknowledged events should not pile up past the send buffer")
+	_, ok := h.PollQueue("token")
+	assert.False(t, ok)
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, policy := range []hub.OverflowPolicy{hub.DropNewest, hub.DropOldest, hub.Disconnect} {
+		parsed, err := hub.ParseOverflowPolicy(string(policy))
+		assert.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := hub.ParseOverflowPolicy("block")
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+var (
+	ErrPollInProgress = errors.New("another poll is in progress")
+	ErrPollClosed     = errors.New("poll queue is closed")
+)
+
+// PollQueue keeps a subscription alive between the long-poll requests of one client. Events stay pending until a
+// later poll acknowledges them with a higher since, so a response lost in transit is delivered again. The subscription
+// follows the overflow policy of the hub, but the queue is always closed with ErrSlowConsumer when more events than
+// the send buffer wait to be acknowledged, the client then opens a new one from the last event it received.
+type PollQueue struct {
+	hub          *Hub
+	key          string
+	userID       string
+	subscription *Subscription
+	pending      []Event
+	busy         chan struct{}
+	idleTimeout  time.Duration
+	expiry       *clock.Timer
+	onClose      func()
+	closeOnce    sync.Once
+}
+
+// OpenPollQueue returns the queue kept under key, or subscribes userID and keeps the new queue under key. A queue
+// that is not polled for idleTimeout is closed and onClose is called once.
+func (h *Hub) OpenPollQueue(key string, userID string, lastEventID uint64, idleTimeout time.Duration, onClose func()) (*PollQueue, bool) {
+	h.mu.Lock()
+	if queue, ok := h.pollQueues[key]; ok {
+		h.mu.Unlock()
+		return queue, false
+	}
+	queue := &PollQueue{
+		hub:          h,
+		key:          key,
+		userID:       userID,
+		subscription: h.subscribe(userID, lastEventID, h.overflowPolicy),
+		busy:         make(chan struct{}, 1),
+		idleTimeout:  idleTimeout,
+		onClose:      onClose,
+	}
+	queue.expiry = h.clock.AfterFunc(idleTimeout, func() { queue.Close() })
+	h.pollQueues[key] = queue
+	h.mu.Unlock()
+
+	return queue, true
+}
+
+func (h *Hub) PollQueue(key string) (*PollQueue, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	queue, ok := h.pollQueues[key]
+	return queue, ok
+}
+
+func (q *PollQueue) UserID() string {
+	return q.userID
+}
+
+// Poll acknowledges pending events up to since and waits until there is at least one event to deliver, the timeout
+// passes or ctx is done. Only one poll per queue may wait at a time.
+func (q *PollQueue) Poll(ctx context.Context, since uint64, timeout time.Duration) ([]Event, error) {
+	select {
+	case q.busy <- struct{}{}:
+	default:
+		return nil, ErrPollInProgress
+	}
+	defer func() { <-q.busy }()
+
+	q.expiry.Stop()
+	defer q.expiry.Reset(q.idleTimeout)
+
+	q.acknowledge(since)
+	if len(q.pending) == 0 {
+		timer := q.hub.clock.Timer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-timer.C:
+			return nil, nil
+		case event, ok := <-q.subscription.Events():
+			if !ok {
+				return nil, q.ended()
+			}
+			q.pending = append(q.pending, event)
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-q.subscription.Events():
+			if !ok {
+				return nil, q.ended()
+			}
+			q.pending = append(q.pending, event)
+			if len(q.pending) > q.hub.sendBuffer {
+				q.Close()
+				return nil, ErrSlowConsumer
+			}
+		default:
+			q.acknowledge(since)
+			return append([]Event(nil), q.pending...), nil
+		}
+	}
+}
+
+// ended returns why the subscription of the queue was closed, a slow consumer also closes the queue.
+func (q *PollQueue) ended() error {
+	err := q.subscription.Err()
+	if err == nil {
+		return ErrPollClosed
+	}
+	q.Close()
+
+	return err
+}
+
+func (q *PollQueue) acknowledge(since uint64) {
+	i := 0
+	for i < len(q.pending) && q.pending[i].ID <= since {
+		i++
+	}
+	q.pending = q.pending[i:]
+}
+
+// Close drops the queue and its subscription, it is safe to call more than once.
+func (q *PollQueue) Close() error {
+	q.closeOnce.Do(func() {
+		q.hub.mu.Lock()
+		if q.hub.pollQueues[q.key] == q {
+			delete(q.hub.pollQueues, q.key)
+		}
+		q.hub.mu.Unlock()
+
+		q.expiry.Stop()
+		q.subscription.Close()
+		if q.onClose != nil {
+			q.onClose()
+		}
+	})
+
+	return nil
+}
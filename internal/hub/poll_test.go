@@ -0,0 +1,138 @@
+package hub_test
+
+import (
+	"context"
+	"httpserver/internal/hub"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_OpenPollQueueReusesKey(t *testing.T) {
+	h := hub.New()
+
+	first, created := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+	assert.True(t, created)
+	second, created := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+	assert.False(t, created)
+	assert.Same(t, first, second)
+
+	queue, ok := h.PollQueue("token")
+	assert.True(t, ok)
+	assert.Same(t, first, queue)
+	assert.Equal(t, "user-1", queue.UserID())
+}
+
+func TestPollQueue_PollTimeout(t *testing.T) {
+	mock := clock.NewMock()
+	h := hub.New(hub.WithClock(mock))
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Hour, nil)
+
+	done := make(chan []hub.Event)
+	go func() {
+		events, err := queue.Poll(context.Background(), 0, time.Minute)
+		assert.NoError(t, err)
+		done <- events
+	}()
+
+	// The poll may not have started its timer yet, so the clock keeps moving until it returns.
+	assert.Eventually(t, func() bool {
+		mock.Add(time.Minute)
+		select {
+		case events := <-done:
+			return assert.Empty(t, events)
+		default:
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPollQueue_PollWaitsForEvent(t *testing.T) {
+	h := hub.New()
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "hello"})
+	}()
+
+	events, err := queue.Poll(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, hub.EventMessage, events[0].Type)
+}
+
+func TestPollQueue_RedeliversUntilAcknowledged(t *testing.T) {
+	h := hub.New()
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+	first, _ := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "first"})
+	second, _ := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "second"})
+
+	events, err := queue.Poll(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{first, second}, events)
+
+	events, err = queue.Poll(context.Background(), 0, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{first, second}, events)
+
+	events, err = queue.Poll(context.Background(), first.ID, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{second}, events)
+
+	events, err = queue.Poll(context.Background(), second.ID, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestPollQueue_RejectsConcurrentPoll(t *testing.T) {
+	h := hub.New()
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, nil)
+
+	done := make(chan struct{})
+	go func() {
+		queue.Poll(context.Background(), 0, 200*time.Millisecond)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := queue.Poll(context.Background(), 0, time.Millisecond)
+	assert.ErrorIs(t, err, hub.ErrPollInProgress)
+	<-done
+}
+
+func TestPollQueue_ExpiresWhenIdle(t *testing.T) {
+	mock := clock.NewMock()
+	h := hub.New(hub.WithClock(mock))
+	closed := make(chan struct{})
+	h.OpenPollQueue("token", "user-1", 0, time.Minute, func() { close(closed) })
+
+	mock.Add(time.Minute)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("poll queue did not expire")
+	}
+	_, ok := h.PollQueue("token")
+	assert.False(t, ok)
+}
+
+func TestPollQueue_CloseEndsPoll(t *testing.T) {
+	h := hub.New()
+	calls := 0
+	queue, _ := h.OpenPollQueue("token", "user-1", 0, time.Minute, func() { calls++ })
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.Close()
+	}()
+
+	_, err := queue.Poll(context.Background(), 0, time.Second)
+	assert.ErrorIs(t, err, hub.ErrPollClosed)
+	assert.NoError(t, queue.Close())
+	assert.Equal(t, 1, calls)
+}
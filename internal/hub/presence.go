@@ -0,0 +1,260 @@
+package hub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// presenceHeartbeat lists the users connected to the node, presenceSync asks every node for one and presenceLeave
+	// is sent by a node that stops.
+	presenceHeartbeat = "heartbeat"
+	presenceSync      = "sync"
+	presenceLeave     = "leave"
+
+	defaultPresenceInterval = 10 * time.Second
+	// missedHeartbeats is how many heartbeats a node may miss before its users are considered offline.
+	missedHeartbeats = 3
+)
+
+// node is what a hub knows about the users connected to an instance, its own included.
+type node struct {
+	users map[string]PresenceData
+	// changed keeps the id of the last presence event of each user, so a heartbeat older than the event does not
+	// undo it.
+	changed  map[string]uint64
+	lastSeen time.Time
+}
+
+func newNode() *node {
+	return &node{users: map[string]PresenceData{}, changed: map[string]uint64{}}
+}
+
+// WithPresenceInterval sets how often the hub announces its users to the other instances. The users of an instance
+// that missed three announcements are considered offline.
+func WithPresenceInterval(interval time.Duration) Option {
+	return func(h *Hub) {
+		if interval > 0 {
+			h.presenceInterval = interval
+		}
+	}
+}
+
+// joinPresence asks the other instances for their users, so users connected before this hub started are known
+// without waiting for their next heartbeat, and starts announcing its own.
+func (h *Hub) joinPresence() {
+	h.stopHeartbeat = make(chan struct{})
+	h.heartbeatDone = make(chan struct{})
+
+	h.sendPresence(presenceSync)
+	go h.heartbeat()
+}
+
+func (h *Hub) heartbeat() {
+	defer close(h.heartbeatDone)
+
+	ticker := h.clock.Ticker(h.presenceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stopHeartbeat:
+			return
+		case <-ticker.C:
+			h.sendPresence(presenceHeartbeat)
+			h.expireNodes(h.clock.Now())
+		}
+	}
+}
+
+// leavePresence stops the heartbeat and tells the other instances that the users of this hub are gone.
+func (h *Hub) leavePresence() {
+	if h.stopHeartbeat == nil {
+		return
+	}
+
+	close(h.stopHeartbeat)
+	<-h.heartbeatDone
+	h.stopHeartbeat = nil
+	h.sendPresence(presenceLeave)
+}
+
+func (h *Hub) sendPresence(kind string) {
+	message := relayedEvent{Node: h.node, Kind: kind}
+	if kind == presenceHeartbeat {
+		h.mu.Lock()
+		message.Users = h.nodes[h.node].usersList()
+		message.LastEventID = h.lastEventID
+		h.mu.Unlock()
+	}
+
+	h.send(message)
+}
+
+func (h *Hub) receivePresence(message relayedEvent) {
+	if message.Node == h.node {
+		return
+	}
+
+	switch message.Kind {
+	case presenceSync:
+		// Handlers must not publish, the heartbeat is sent once the message was handled.
+		go h.sendPresence(presenceHeartbeat)
+	case presenceHeartbeat:
+		h.applyHeartbeat(message.Node, message.Users, message.LastEventID, h.clock.Now())
+	case presenceLeave:
+		h.forgetNode(message.Node, h.clock.Now())
+	}
+}
+
+// applyHeartbeat replaces the users of an instance with the ones it announced as of lastEventID, keeping the
+// presence events received since, and reports the users who came and went to the remote handler.
+func (h *Hub) applyHeartbeat(name string, users []PresenceData, lastEventID uint64, now time.Time) {
+	h.mu.Lock()
+	n, ok := h.nodes[name]
+	if !ok {
+		n = newNode()
+		h.nodes[name] = n
+	}
+	announced := make(map[string]PresenceData, len(users))
+	for _, presence := range users {
+		announced[presence.UserID] = presence
+	}
+	for userID, id := range n.changed {
+		if id <= lastEventID {
+			delete(n.changed, userID)
+			continue
+		}
+		if presence, ok := n.users[userID]; ok {
+			announced[userID] = presence
+		} else {
+			delete(announced, userID)
+		}
+	}
+	came, went := diffUsers(n.users, announced)
+	n.users = announced
+	n.lastSeen = now
+	h.mu.Unlock()
+
+	h.presenceChanged(came, PresenceOnline, now)
+	h.presenceChanged(went, PresenceOffline, now)
+}
+
+// forgetNode drops an instance that left or stopped sending heartbeats, its users are reported offline.
+func (h *Hub) forgetNode(name string, now time.Time) {
+	h.mu.Lock()
+	n, ok := h.nodes[name]
+	delete(h.nodes, name)
+	h.mu.Unlock()
+
+	if ok {
+		h.presenceChanged(n.usersList(), PresenceOffline, now)
+	}
+}
+
+// expireNodes forgets the instances that missed their heartbeats, they stopped without saying so.
+func (h *Hub) expireNodes(now time.Time) {
+	h.mu.Lock()
+	var expired []string
+	for name, n := range h.nodes {
+		if name != h.node && now.Sub(n.lastSeen) > missedHeartbeats*h.presenceInterval {
+			expired = append(expired, name)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, name := range expired {
+		h.forgetNode(name, now)
+	}
+}
+
+// presenceChanged reports changes of presence that were not published as events, they are only seen by the remote
+// handler and not by the subscribers.
+func (h *Hub) presenceChanged(users []PresenceData, status string, now time.Time) {
+	if h.onRemote == nil {
+		return
+	}
+
+	for _, presence := range users {
+		presence.Status = status
+		data, err := json.Marshal(presence)
+		if err != nil {
+			continue
+		}
+		h.onRemote(Event{Type: EventPresence, Time: now.UTC(), Data: data})
+	}
+}
+
+// leaveNode stops announcing a user without connections in the heartbeats of this instance. No offline event is
+// published while the user is connected to another instance, so a heartbeat is sent at once to tell the others. It
+// must be called with the hub locked.
+func (h *Hub) leaveNode(userID string) {
+	n, ok := h.nodes[h.node]
+	if !ok {
+		return
+	}
+	if _, ok := n.users[userID]; !ok {
+		return
+	}
+	delete(n.users, userID)
+
+	for name, other := range h.nodes {
+		if _, ok := other.users[userID]; ok && name != h.node {
+			go h.sendPresence(presenceHeartbeat)
+			return
+		}
+	}
+}
+
+// trackPresence follows the presence events of every instance between heartbeats, it must be called with the hub
+// locked.
+func (h *Hub) trackPresence(name string, event Event) {
+	var presence PresenceData
+	if err := json.Unmarshal(event.Data, &presence); err != nil {
+		return
+	}
+
+	n, ok := h.nodes[name]
+	if !ok {
+		n = newNode()
+		h.nodes[name] = n
+	}
+	n.lastSeen = h.clock.Now()
+	if name != h.node {
+		n.changed[presence.UserID] = event.ID
+	}
+	switch presence.Status {
+	case PresenceOnline:
+		n.users[presence.UserID] = presence
+	case PresenceOffline:
+		delete(n.users, presence.UserID)
+	}
+}
+
+func (n *node) usersList() []PresenceData {
+	if n == nil {
+		return nil
+	}
+
+	users := make([]PresenceData, 0, len(n.users))
+	for _, presence := range n.users {
+		users = append(users, presence)
+	}
+
+	return users
+}
+
+func diffUsers(previous map[string]PresenceData, next map[string]PresenceData) (came []PresenceData, went []PresenceData) {
+	for userID, presence := range next {
+		if _, ok := previous[userID]; !ok {
+			came = append(came, presence)
+		}
+	}
+	for userID, presence := range previous {
+		if _, ok := next[userID]; !ok {
+			went = append(went, presence)
+		}
+	}
+
+	return came, went
+}
@@ -0,0 +1,110 @@
+package hub_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"httpserver/internal/hub"
+	"httpserver/internal/pubsub"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// presenceLog collects the presence the remote handler of a hub was told about.
+type presenceLog struct {
+	mu       sync.Mutex
+	statuses []string
+}
+
+func (l *presenceLog) handle(event hub.Event) {
+	var presence hub.PresenceData
+	json.Unmarshal(event.Data, &presence)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statuses = append(l.statuses, presence.UserID+" "+presence.Status)
+}
+
+func (l *presenceLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.statuses...)
+}
+
+func goOnline(t *testing.T, h *hub.Hub, userID string) {
+	t.Helper()
+
+	_, err := h.Publish(hub.EventPresence, userID, "", hub.PresenceData{UserID: userID, Status: hub.PresenceOnline})
+	require.NoError(t, err)
+}
+
+func TestHub_JoiningHubAsksForPresence(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	goOnline(t, first, "user-1")
+
+	var log presenceLog
+	second := hub.New(hub.WithPubSub(ps), hub.WithRemoteHandler(log.handle))
+	defer second.Close()
+
+	assert.Eventually(t, func() bool { return second.Online("user-1") }, time.Second, 5*time.Millisecond,
+		"Users connected before the hub started should be known without waiting for a heartbeat")
+	assert.Equal(t, []string{"user-1 online"}, log.all())
+}
+
+func TestHub_ClosedHubTakesItsUsersAlong(t *testing.T) {
+	ps := pubsub.NewMemory()
+	var log presenceLog
+	first := hub.New(hub.WithPubSub(ps), hub.WithRemoteHandler(log.handle))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps))
+	goOnline(t, second, "user-2")
+	require.True(t, first.Online("user-2"))
+
+	second.Close()
+
+	assert.False(t, first.Online("user-2"))
+	assert.Equal(t, []string{"user-2 online", "user-2 offline"}, log.all())
+}
+
+func TestHub_SilentHubExpires(t *testing.T) {
+	ps := pubsub.NewMemory()
+	var log presenceLog
+	h := hub.New(hub.WithPubSub(ps), hub.WithRemoteHandler(log.handle), hub.WithPresenceInterval(10*time.Millisecond))
+	defer h.Close()
+
+	// An instance that announces its users once, then stops without leaving.
+	_, err := ps.Publish(context.Background(), "hub.events", []byte(`{"node":"gone","kind":"heartbeat","users":[{"userId":"user-3","status":"online"}]}`))
+	require.NoError(t, err)
+	assert.True(t, h.Online("user-3"))
+
+	assert.Eventually(t, func() bool { return !h.Online("user-3") }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"user-3 online", "user-3 offline"}, log.all())
+}
+
+func TestHub_HeartbeatKeepsNewerPresence(t *testing.T) {
+	ps := pubsub.NewMemory()
+	h := hub.New(hub.WithPubSub(ps))
+	defer h.Close()
+
+	id, err := ps.Publish(context.Background(), "hub.events", []byte(`{"node":"other","type":"presence","data":{"userId":"user-4","status":"online"}}`))
+	require.NoError(t, err)
+	require.True(t, h.Online("user-4"))
+
+	// The heartbeat was taken before the other instance received its own presence event.
+	heartbeat := fmt.Sprintf(`{"node":"other","kind":"heartbeat","lastEventId":%d}`, id-1)
+	_, err = ps.Publish(context.Background(), "hub.events", []byte(heartbeat))
+	require.NoError(t, err)
+	assert.True(t, h.Online("user-4"))
+
+	heartbeat = fmt.Sprintf(`{"node":"other","kind":"heartbeat","lastEventId":%d}`, id)
+	_, err = ps.Publish(context.Background(), "hub.events", []byte(heartbeat))
+	require.NoError(t, err)
+	assert.False(t, h.Online("user-4"))
+}
@@ -0,0 +1,192 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"httpserver/internal/pubsub"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	relayChannel = "hub.events"
+	// relayDisconnect asks every instance to close the connections and sessions of a user.
+	relayDisconnect = "disconnect"
+)
+
+// relayedEvent is an event on its way to every instance, its id is the one the pubsub gave the message. Messages with
+// a kind carry the presence of an instance or a disconnect instead, they share the channel so they are ordered with
+// the events.
+type relayedEvent struct {
+	Node string          `json:"node"`
+	Type string          `json:"type,omitempty"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data,omitempty"`
+	From string          `json:"from,omitempty"`
+	To   string          `json:"to,omitempty"`
+
+	Kind        string         `json:"kind,omitempty"`
+	Users       []PresenceData `json:"users,omitempty"`
+	LastEventID uint64         `json:"lastEventId,omitempty"`
+	UserID      string         `json:"userId,omitempty"`
+}
+
+// RelayErrorHandler is called when an event could not be sent to, or received from, the other instances. It is called
+// with an empty event when the presence of the instances or a disconnect could not be exchanged, or when the hub could
+// not subscribe to their events, the hub then only serves its own clients.
+type RelayErrorHandler func(event Event, err error)
+
+// RemoteHandler is called for every event received from another instance, after it was delivered locally. It is also
+// called with presence events without an id for the users of an instance that joined or left, those are not
+// delivered to the subscribers.
+type RemoteHandler func(event Event)
+
+// WithPubSub relays every published event to the hubs of the other instances subscribed to ps, and delivers theirs to
+// the local subscribers. Event ids are the message ids of ps, so every instance orders the events the same way and a
+// client can resume from any instance. Disconnect is relayed too.
+func WithPubSub(ps pubsub.PubSub) Option {
+	return func(h *Hub) {
+		h.pubsub = ps
+	}
+}
+
+func WithRelayErrorHandler(handler RelayErrorHandler) Option {
+	return func(h *Hub) {
+		h.onRelayError = handler
+	}
+}
+
+func WithRemoteHandler(handler RemoteHandler) Option {
+	return func(h *Hub) {
+		h.onRemote = handler
+	}
+}
+
+func (h *Hub) joinRelay() {
+	if h.pubsub == nil {
+		return
+	}
+
+	h.node = uuid.NewString()
+	stop, err := h.pubsub.Subscribe(relayChannel, h.receive)
+	if err != nil {
+		h.relayFailed(Event{}, err)
+		h.pubsub = nil
+		return
+	}
+	h.stopRelay = stop
+	h.joinPresence()
+}
+
+// Close tells the other instances that the users of this hub are gone and stops relaying events between them, it is
+// called when the server shuts down.
+func (h *Hub) Close() {
+	h.leavePresence()
+	if h.stopRelay != nil {
+		h.stopRelay()
+		h.stopRelay = nil
+	}
+}
+
+// Online reports whether the user is connected to this instance or, as far as their presence tells, to another one.
+func (h *Hub) Online(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.connections[userID]) > 0 {
+		return true
+	}
+	for name, n := range h.nodes {
+		if _, ok := n.users[userID]; ok && name != h.node {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Presence returns the presence the user announced on any instance, it tells the name of a user connected to another
+// instance that does not share the storage of this one.
+func (h *Hub) Presence(userID string) (PresenceData, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, n := range h.nodes {
+		if presence, ok := n.users[userID]; ok {
+			return presence, true
+		}
+	}
+
+	return PresenceData{}, false
+}
+
+// relay publishes the event through the pubsub, which gives it its id. Every hub, this one included, appends the
+// event when it receives it.
+func (h *Hub) relay(event Event) (Event, error) {
+	message, err := json.Marshal(relayedEvent{
+		Node: h.node,
+		Type: event.Type,
+		Time: event.Time,
+		Data: event.Data,
+		From: event.From,
+		To:   event.To,
+	})
+	if err == nil {
+		event.ID, err = h.pubsub.Publish(context.Background(), relayChannel, message)
+	}
+	if err != nil {
+		h.relayFailed(event, err)
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+func (h *Hub) receive(id uint64, message []byte) {
+	var relayed relayedEvent
+	if err := json.Unmarshal(message, &relayed); err != nil {
+		h.relayFailed(Event{}, err)
+		return
+	}
+	if relayed.Kind == relayDisconnect {
+		if relayed.Node != h.node {
+			// Handlers must not publish, and closing a connection releases it, which publishes the presence of the user.
+			go h.disconnect(relayed.UserID)
+		}
+		return
+	}
+	if relayed.Kind != "" {
+		h.receivePresence(relayed)
+		return
+	}
+	remote := relayed.Node != h.node
+
+	h.mu.Lock()
+	event := h.append(Event{ID: id, Type: relayed.Type, Time: relayed.Time, Data: relayed.Data, From: relayed.From, To: relayed.To})
+	if event.Type == EventPresence {
+		h.trackPresence(relayed.Node, event)
+	}
+	h.mu.Unlock()
+
+	if remote && h.onRemote != nil {
+		h.onRemote(event)
+	}
+}
+
+// send publishes a message that is not an event, failures are reported with an empty event.
+func (h *Hub) send(message relayedEvent) {
+	data, err := json.Marshal(message)
+	if err == nil {
+		_, err = h.pubsub.Publish(context.Background(), relayChannel, data)
+	}
+	if err != nil {
+		h.relayFailed(Event{}, err)
+	}
+}
+
+func (h *Hub) relayFailed(event Event, err error) {
+	if h.onRelayError != nil {
+		h.onRelayError(event, err)
+	}
+}
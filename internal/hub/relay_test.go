@@ -0,0 +1,191 @@
+package hub_test
+
+import (
+	"context"
+	"errors"
+	"httpserver/internal/hub"
+	"httpserver/internal/pubsub"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPubSub struct {
+	*pubsub.Memory
+}
+
+func (failingPubSub) Publish(ctx context.Context, channel string, message []byte) (uint64, error) {
+	return 0, errors.New("connection refused")
+}
+
+// signalConn is closed by another goroutine when a disconnect is relayed, onClose runs before closed is signalled.
+type signalConn struct {
+	onClose func()
+	closed  chan struct{}
+}
+
+func newSignalConn(onClose func()) *signalConn {
+	return &signalConn{onClose: onClose, closed: make(chan struct{})}
+}
+
+func (c *signalConn) Close() error {
+	if c.onClose != nil {
+		c.onClose()
+	}
+	close(c.closed)
+	return nil
+}
+
+func assertSignalled(t *testing.T, conn *signalConn, message string) {
+	t.Helper()
+
+	select {
+	case <-conn.closed:
+	case <-time.After(time.Second):
+		t.Fatal(message)
+	}
+}
+
+type unreachablePubSub struct {
+	*pubsub.Memory
+}
+
+func (unreachablePubSub) Subscribe(channel string, handler pubsub.Handler) (func(), error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestHub_RelayDeliversEventsOfOtherInstances(t *testing.T) {
+	ps := pubsub.NewMemory()
+	var remote []hub.Event
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps), hub.WithRemoteHandler(func(event hub.Event) {
+		remote = append(remote, event)
+	}))
+	defer second.Close()
+
+	_, err := second.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "local"})
+	require.NoError(t, err)
+	firstSub := first.Subscribe("user-1", 0)
+	secondSub := second.Subscribe("user-3", 0)
+
+	broadcast, err := first.Publish(hub.EventMessage, "user-1", "", hub.MessageData{Text: "hello"})
+	require.NoError(t, err)
+	direct, err := first.Publish(hub.EventMessage, "user-1", "user-2", hub.MessageData{Text: "psst"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []hub.Event{broadcast, direct}, receive(t, firstSub))
+	assert.Equal(t, []hub.Event{broadcast}, receive(t, secondSub), "Both instances should give the event the same id")
+	require.Len(t, remote, 2, "Events of the instance itself are not remote")
+	assert.Equal(t, "user-2", remote[1].To)
+
+	// The direct message was retained by the other instance for its recipient.
+	assert.Equal(t, []hub.Event{broadcast, direct}, receive(t, second.Subscribe("user-2", broadcast.ID-1)))
+}
+
+func TestHub_OnlineFollowsRemotePresence(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps))
+	defer second.Close()
+
+	assert.False(t, second.Online("user-1"))
+
+	_, err := first.Publish(hub.EventPresence, "user-1", "", hub.PresenceData{UserID: "user-1", Status: hub.PresenceOnline})
+	require.NoError(t, err)
+	assert.True(t, second.Online("user-1"))
+	assert.False(t, first.Online("user-1"))
+
+	_, err = first.Publish(hub.EventPresence, "user-1", "", hub.PresenceData{UserID: "user-1", Status: hub.PresenceOffline})
+	require.NoError(t, err)
+	assert.False(t, second.Online("user-1"))
+}
+
+func TestHub_DisconnectReachesOtherInstances(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps))
+	defer second.Close()
+	local, remote, other := &fakeConn{}, newSignalConn(nil), &fakeConn{}
+	first.Register("user-1", local)
+	second.Register("user-1", remote)
+	second.Register("user-2", other)
+
+	assert.Equal(t, 1, first.Disconnect("user-1"), "Only the connections of the instance itself are counted")
+	assert.True(t, local.closed)
+	assertSignalled(t, remote, "the connection of the other instance was not closed")
+	assert.Equal(t, 0, second.Count("user-1"))
+	assert.Equal(t, 1, second.Count("user-2"))
+	assert.False(t, other.closed)
+}
+
+func TestHub_RelayedDisconnectMayPublish(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps))
+	defer second.Close()
+	// Like a connection announcing its user offline when it is released.
+	conn := newSignalConn(func() {
+		second.Publish(hub.EventPresence, "user-1", "", hub.PresenceData{UserID: "user-1", Status: hub.PresenceOffline})
+	})
+	second.Register("user-1", conn)
+
+	first.Disconnect("user-1")
+
+	assertSignalled(t, conn, "the connection closed by a relayed disconnect could not publish")
+}
+
+func TestHub_PresenceOfOtherInstances(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first := hub.New(hub.WithPubSub(ps))
+	defer first.Close()
+	second := hub.New(hub.WithPubSub(ps))
+	defer second.Close()
+
+	_, ok := second.Presence("user-1")
+	assert.False(t, ok)
+
+	_, err := first.Publish(hub.EventPresence, "user-1", "", hub.PresenceData{UserID: "user-1", UserName: "JohnDoe", Status: hub.PresenceOnline})
+	require.NoError(t, err)
+	presence, ok := second.Presence("user-1")
+	assert.True(t, ok)
+	assert.Equal(t, "JohnDoe", presence.UserName)
+}
+
+func TestHub_RelayErrorIsReported(t *testing.T) {
+	var failed []error
+	h := hub.New(hub.WithPubSub(failingPubSub{Memory: pubsub.NewMemory()}), hub.WithRelayErrorHandler(func(event hub.Event, err error) {
+		failed = append(failed, err)
+	}))
+	defer h.Close()
+	// Asking the other instances for their presence failed as well.
+	failed = nil
+	subscription := h.Subscribe("user-1", 0)
+
+	_, err := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "hello"})
+	assert.EqualError(t, err, "connection refused")
+
+	assert.Empty(t, receive(t, subscription), "An event without an id should not be delivered")
+	require.Len(t, failed, 1)
+	assert.EqualError(t, failed[0], "connection refused")
+}
+
+func TestHub_RelaySubscribeErrorIsReported(t *testing.T) {
+	var failed []error
+	h := hub.New(hub.WithPubSub(unreachablePubSub{Memory: pubsub.NewMemory()}), hub.WithRelayErrorHandler(func(event hub.Event, err error) {
+		failed = append(failed, err)
+	}))
+	defer h.Close()
+
+	require.Len(t, failed, 1)
+	assert.EqualError(t, failed[0], "connection refused")
+
+	event, err := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), event.ID, "The hub should give ids itself when it could not subscribe")
+}
@@ -0,0 +1,186 @@
+package hub
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	maxUnacked   = 1024
+	maxPublished = 256
+)
+
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session keeps the delivery state of a WebSocket client across reconnects: events sent but not acknowledged, the
+// last event sent and the ids of messages the client already published. It expires when no connection is attached
+// for its ttl, starting when it is opened.
+type Session struct {
+	hub    *Hub
+	token  string
+	userID string
+	ttl    time.Duration
+
+	mu             sync.Mutex
+	conn           io.Closer
+	unacked        []Event
+	lastSent       uint64
+	published      map[string]Event
+	publishedOrder []string
+	expiry         *clock.Timer
+	closed         bool
+}
+
+func (h *Hub) OpenSession(token string, userID string, ttl time.Duration) *Session {
+	session := &Session{
+		hub:       h,
+		token:     token,
+		userID:    userID,
+		ttl:       ttl,
+		published: map[string]Event{},
+	}
+
+	session.expiry = h.clock.AfterFunc(ttl, func() { session.Close() })
+
+	h.mu.Lock()
+	session.lastSent = h.lastEventID
+	h.sessions[token] = session
+	h.mu.Unlock()
+
+	return session
+}
+
+func (h *Hub) Session(token string) (*Session, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	session, ok := h.sessions[token]
+	return session, ok
+}
+
+func (s *Session) Token() string {
+	return s.token
+}
+
+func (s *Session) UserID() string {
+	return s.userID
+}
+
+// Attach makes conn the connection of the session, closing the previous one. It returns the unacknowledged events to
+// send again and a subscription replaying the retained events published after the last one sent. The subscription
+// follows the overflow policy of the hub: with Disconnect every event after the last one sent is replayed on the next
+// Attach, the drop policies skip the events that did not fit.
+func (s *Session) Attach(conn io.Closer) (*Subscription, []Event, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, nil, ErrSessionClosed
+	}
+	s.expiry.Stop()
+	previous := s.conn
+	s.conn = conn
+	unacked := append([]Event(nil), s.unacked...)
+	lastSent := s.lastSent
+	s.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.hub.subscribe(s.userID, lastSent, s.hub.overflowPolicy), unacked, nil
+}
+
+// Detach starts the expiry of the session unless another connection took it over.
+func (s *Session) Detach(conn io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.conn != conn {
+		return
+	}
+	s.conn = nil
+	s.expiry.Reset(s.ttl)
+}
+
+// Sent records an event written to the client, it is kept until acknowledged.
+func (s *Session) Sent(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.ID <= s.lastSent {
+		return
+	}
+	s.lastSent = event.ID
+	s.unacked = append(s.unacked, event)
+	if len(s.unacked) > maxUnacked {
+		s.unacked = s.unacked[len(s.unacked)-maxUnacked:]
+	}
+}
+
+// Ack drops every unacknowledged event up to and including id.
+func (s *Session) Ack(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := 0
+	for i < len(s.unacked) && s.unacked[i].ID <= id {
+		i++
+	}
+	s.unacked = s.unacked[i:]
+}
+
+// Published returns the event created for a client message id, so a message sent again after a reconnect is not
+// published twice.
+func (s *Session) Published(clientID string) (Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.published[clientID]
+	return event, ok
+}
+
+func (s *Session) RememberPublished(clientID string, event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.published[clientID]; ok {
+		return
+	}
+	s.published[clientID] = event
+	s.publishedOrder = append(s.publishedOrder, clientID)
+	if len(s.publishedOrder) > maxPublished {
+		delete(s.published, s.publishedOrder[0])
+		s.publishedOrder = s.publishedOrder[1:]
+	}
+}
+
+// Close drops the session and closes its connection, it is safe to call more than once.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.expiry.Stop()
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+
+	s.hub.mu.Lock()
+	delete(s.hub.sessions, s.token)
+	s.hub.mu.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+
+	return nil
+}
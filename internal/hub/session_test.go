@@ -0,0 +1,108 @@
+package hub_test
+
+import (
+	"httpserver/internal/hub"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_RetransmitsUnackedOnAttach(t *testing.T) {
+	h := hub.New()
+	session := h.OpenSession("token", "user-1", time.Minute)
+	conn := &fakeConn{}
+
+	subscription, unacked, err := session.Attach(conn)
+	require.NoError(t, err)
+	assert.Empty(t, unacked)
+
+	first, _ := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "first"})
+	second, _ := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "second"})
+	for _, event := range receive(t, subscription) {
+		session.Sent(event)
+	}
+	session.Ack(first.ID)
+	subscription.Close()
+	session.Detach(conn)
+
+	third, _ := h.Publish(hub.EventMessage, "user-2", "", hub.MessageData{Text: "third"})
+
+	subscription, unacked, err = session.Attach(&fakeConn{})
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{second}, unacked)
+	assert.Equal(t, []hub.Event{third}, receive(t, subscription))
+}
+
+func TestSession_AttachClosesPreviousConnection(t *testing.T) {
+	h := hub.New()
+	session := h.OpenSession("token", "user-1", time.Minute)
+	first, second := &fakeConn{}, &fakeConn{}
+
+	_, _, err := session.Attach(first)
+	require.NoError(t, err)
+	_, _, err = session.Attach(second)
+	require.NoError(t, err)
+	session.Detach(first)
+
+	assert.True(t, first.closed)
+	assert.False(t, second.closed)
+	_, ok := h.Session("token")
+	assert.True(t, ok)
+}
+
+func TestSession_ExpiresWhenDetached(t *testing.T) {
+	mock := clock.NewMock()
+	h := hub.New(hub.WithClock(mock))
+	session := h.OpenSession("token", "user-1", time.Minute)
+	conn := &fakeConn{}
+
+	_, _, err := session.Attach(conn)
+	require.NoError(t, err)
+	mock.Add(2 * time.Minute)
+	_, ok := h.Session("token")
+	assert.True(t, ok)
+
+	session.Detach(conn)
+	mock.Add(time.Minute)
+	assert.Eventually(t, func() bool {
+		_, ok := h.Session("token")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	_, _, err = session.Attach(conn)
+	assert.ErrorIs(t, err, hub.ErrSessionClosed)
+}
+
+func TestSession_Published(t *testing.T) {
+	h := hub.New()
+	session := h.OpenSession("token", "user-1", time.Minute)
+	event, _ := h.Publish(hub.EventMessage, "user-1", "", hub.MessageData{Text: "hello"})
+
+	_, ok := session.Published("client-1")
+	assert.False(t, ok)
+
+	session.RememberPublished("client-1", event)
+	published, ok := session.Published("client-1")
+	assert.True(t, ok)
+	assert.Equal(t, event, published)
+}
+
+func TestHub_DisconnectClosesSessions(t *testing.T) {
+	h := hub.New()
+	session := h.OpenSession("token", "user-1", time.Minute)
+	other := h.OpenSession("other", "user-2", time.Minute)
+	conn := &fakeConn{}
+	_, _, err := session.Attach(conn)
+	require.NoError(t, err)
+
+	h.Disconnect("user-1")
+
+	assert.True(t, conn.closed)
+	_, ok := h.Session("token")
+	assert.False(t, ok)
+	_, ok = h.Session(other.Token())
+	assert.True(t, ok)
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	userKey
+)
+
+type requestUser struct {
+	name string
+}
+
+func NewContext(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.SugaredLogger); ok {
+		return logger
+	}
+
+	return zap.S()
+}
+
+func SetUser(ctx context.Context, userName string) {
+	if user, ok := ctx.Value(userKey).(*requestUser); ok {
+		user.name = userName
+	}
+}
+
+func withUser(ctx context.Context) (context.Context, *requestUser) {
+	user := &requestUser{}
+	return context.WithValue(ctx, userKey, user), user
+}
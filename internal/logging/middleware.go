@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// redactedParams carry credentials, WebSocket and event stream clients that cannot set headers send them in the query.
+var redactedParams = []string{"token", "session"}
+
+func Middleware(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			requestLogger := logger
+			if requestID := middleware.GetReqID(r.Context()); requestID != "" {
+				requestLogger = requestLogger.With(zap.String("request_id", requestID))
+			}
+
+			ctx, user := withUser(NewContext(r.Context(), requestLogger.Sugar()))
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("route", routePattern(r)),
+				zap.Int("status", status),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+			}
+			if r.URL.RawQuery != "" {
+				fields = append(fields, zap.String("query", redactQuery(r.URL)))
+			}
+			if user.name != "" {
+				fields = append(fields, zap.String("user", user.name))
+			}
+
+			requestLogger.Info("request", fields...)
+		})
+	}
+}
+
+func redactQuery(u *url.URL) string {
+	query := u.Query()
+	for _, name := range redactedParams {
+		if _, ok := query[name]; ok {
+			query.Set(name, "REDACTED")
+		}
+	}
+
+	return query.Encode()
+}
+
+func routePattern(r *http.Request) string {
+	if routeContext := chi.RouteContext(r.Context()); routeContext != nil {
+		return routeContext.RoutePattern()
+	}
+
+	return ""
+}
@@ -0,0 +1,80 @@
+package logging_test
+
+import (
+	"httpserver/internal/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware_LogsRequest(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(logging.Middleware(zap.New(core)))
+	router.Get("/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUser(r.Context(), "JohnDoe")
+		logging.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/42", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, 2, logs.Len())
+
+	handled := logs.All()[0]
+	assert.Equal(t, "handled", handled.Message)
+	assert.NotEmpty(t, handled.ContextMap()["request_id"])
+
+	access := logs.All()[1].ContextMap()
+	assert.Equal(t, handled.ContextMap()["request_id"], access["request_id"])
+	assert.Equal(t, "/user/{id}", access["route"])
+	assert.Equal(t, int64(http.StatusAccepted), access["status"])
+	assert.Equal(t, int64(2), access["bytes"])
+	assert.Equal(t, "JohnDoe", access["user"])
+}
+
+func TestMiddleware_AnonymousRequest(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := chi.NewRouter()
+	router.Use(logging.Middleware(zap.New(core)))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, 1, logs.Len())
+	access := logs.All()[0].ContextMap()
+	assert.Equal(t, int64(http.StatusOK), access["status"])
+	assert.NotContains(t, access, "user")
+}
+
+func TestFromContext_Fallback(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert.NotNil(t, logging.FromContext(req.Context()))
+}
+
+func TestMiddleware_RedactsCredentialsInQuery(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := chi.NewRouter()
+	router.Use(logging.Middleware(zap.New(core)))
+	router.Get("/ws", func(w http.ResponseWriter, r *http.Request) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws?token=secret&session=other&lastEventId=5", nil))
+
+	access := logs.All()[0].ContextMap()
+	assert.Equal(t, "lastEventId=5&session=REDACTED&token=REDACTED", access["query"])
+	assert.NotContains(t, access["query"], "secret")
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	HttpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and chi route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	UserRegistrationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "user_registrations_total",
+		Help: "Number of successfully registered users.",
+	})
+
+	UserLoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_logins_total",
+		Help: "Number of login attempts by result.",
+	}, []string{"result"})
+
+	WebsocketActiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "websocket_active_connections",
+		Help: "Number of currently open WebSocket connections.",
+	})
+
+	WebsocketMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_messages_total",
+		Help: "Number of WebSocket messages by direction and event type.",
+	}, []string{"direction", "event"})
+
+	WebsocketDisconnectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_disconnects_total",
+		Help: "Number of WebSocket connections closed by the server by reason.",
+	}, []string{"reason"})
+
+	WebsocketRateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_rate_limited_total",
+		Help: "Number of inbound WebSocket envelopes over their rate by event type and action taken.",
+	}, []string{"event", "action"})
+
+	SseActiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "sse_active_connections",
+		Help: "Number of currently open Server-Sent Events streams.",
+	})
+
+	PollActiveQueues = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "poll_active_queues",
+		Help: "Number of long-polling event queues kept between polls.",
+	})
+
+	HubDroppedEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hub_dropped_events_total",
+		Help: "Number of events not delivered to a subscriber because its send buffer was full, by overflow policy.",
+	}, []string{"policy"})
+
+	HubRelayErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hub_relay_errors_total",
+		Help: "Number of events that could not be relayed to or from the other instances.",
+	})
+
+	StorageOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_operation_duration_seconds",
+		Help:    "Storage operation latency by storage and operation.",
+		Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1},
+	}, []string{"storage", "operation"})
+)
+
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequestsTotal,
+		HttpRequestDuration,
+		UserRegistrationsTotal,
+		UserLoginsTotal,
+		WebsocketActiveConnections,
+		WebsocketMessagesTotal,
+		WebsocketDisconnectsTotal,
+		WebsocketRateLimitedTotal,
+		SseActiveConnections,
+		PollActiveQueues,
+		HubDroppedEventsTotal,
+		HubRelayErrorsTotal,
+		StorageOperationDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
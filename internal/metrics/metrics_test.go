@@ -0,0 +1,56 @@
+package metrics_test
+
+import (
+	"httpserver/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_RecordsRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(metrics.Middleware)
+	router.Get("/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	counter := metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, "/user/{id}", "202")
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/42", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestMiddleware_UnmatchedRoute(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(metrics.Middleware)
+	router.Get("/user", func(w http.ResponseWriter, r *http.Request) {})
+
+	counter := metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, "unmatched", "404")
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestHandler(t *testing.T) {
+	metrics.UserRegistrationsTotal.Inc()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "user_registrations_total")
+	assert.Contains(t, w.Body.String(), "websocket_active_connections")
+}
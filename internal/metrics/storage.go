@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"context"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/resettokenstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"time"
+)
+
+func observeStorage(storageName string, operation string, start time.Time) {
+	StorageOperationDuration.WithLabelValues(storageName, operation).Observe(time.Since(start).Seconds())
+}
+
+type userStorage struct {
+	next userstorage.UserStorageInterface
+}
+
+func NewUserStorage(next userstorage.UserStorageInterface) userstorage.UserStorageInterface {
+	return &userStorage{next: next}
+}
+
+func (s *userStorage) Add(ctx context.Context, userName string, password string) (string, error) {
+	defer observeStorage("user", "add", time.Now())
+	return s.next.Add(ctx, userName, password)
+}
+
+func (s *userStorage) Get(ctx context.Context, userName string) (*storage.User, error) {
+	defer observeStorage("user", "get", time.Now())
+	return s.next.Get(ctx, userName)
+}
+
+func (s *userStorage) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	defer observeStorage("user", "get_by_id", time.Now())
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *userStorage) Update(ctx context.Context, user *storage.User) error {
+	defer observeStorage("user", "update", time.Now())
+	return s.next.Update(ctx, user)
+}
+
+func (s *userStorage) Delete(ctx context.Context, id string) error {
+	defer observeStorage("user", "delete", time.Now())
+	return s.next.Delete(ctx, id)
+}
+
+func (s *userStorage) List(ctx context.Context, offset int, limit int) ([]*storage.User, int) {
+	defer observeStorage("user", "list", time.Now())
+	return s.next.List(ctx, offset, limit)
+}
+
+type tokenStorage struct {
+	next tokenstorage.TokenStorageInterface
+}
+
+func NewTokenStorage(next tokenstorage.TokenStorageInterface) tokenstorage.TokenStorageInterface {
+	return &tokenStorage{next: next}
+}
+
+func (s *tokenStorage) Add(ctx context.Context, token string, userID string) {
+	defer observeStorage("token", "add", time.Now())
+	s.next.Add(ctx, token, userID)
+}
+
+func (s *tokenStorage) Get(ctx context.Context, token string) (string, error) {
+	defer observeStorage("token", "get", time.Now())
+	return s.next.Get(ctx, token)
+}
+
+func (s *tokenStorage) Delete(ctx context.Context, token string) {
+	defer observeStorage("token", "delete", time.Now())
+	s.next.Delete(ctx, token)
+}
+
+func (s *tokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	defer observeStorage("token", "delete_by_user", time.Now())
+	s.next.DeleteByUser(ctx, userID)
+}
+
+type activeUsersStorage struct {
+	next activeuserstorage.ActiveUsersStorageInterface
+}
+
+func NewActiveUsersStorage(next activeuserstorage.ActiveUsersStorageInterface) activeuserstorage.ActiveUsersStorageInterface {
+	return &activeUsersStorage{next: next}
+}
+
+func (s *activeUsersStorage) Add(ctx context.Context, userID string) {
+	defer observeStorage("active_user", "add", time.Now())
+	s.next.Add(ctx, userID)
+}
+
+func (s *activeUsersStorage) Get(ctx context.Context, id string) (*storage.User, error) {
+	defer observeStorage("active_user", "get", time.Now())
+	return s.next.Get(ctx, id)
+}
+
+func (s *activeUsersStorage) Delete(ctx context.Context, userID string) {
+	defer observeStorage("active_user", "delete", time.Now())
+	s.next.Delete(ctx, userID)
+}
+
+func (s *activeUsersStorage) GetNames(ctx context.Context) []string {
+	defer observeStorage("active_user", "get_names", time.Now())
+	return s.next.GetNames(ctx)
+}
+
+func (s *activeUsersStorage) List(ctx context.Context, query activeuserstorage.Query) ([]activeuserstorage.ActiveUser, int) {
+	defer observeStorage("active_user", "list", time.Now())
+	return s.next.List(ctx, query)
+}
+
+type sessionStorage struct {
+	name string
+	next sessionstorage.SessionStorageInterface
+}
+
+func NewSessionStorage(next sessionstorage.SessionStorageInterface) sessionstorage.SessionStorageInterface {
+	return &sessionStorage{name: "session", next: next}
+}
+
+// NewStreamTokenStorage wraps the storage of the stream tokens, it is a session storage reported under its own name.
+func NewStreamTokenStorage(next sessionstorage.SessionStorageInterface) sessionstorage.SessionStorageInterface {
+	return &sessionStorage{name: "stream_token", next: next}
+}
+
+func (s *sessionStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	defer observeStorage(s.name, "add", time.Now())
+	s.next.Add(ctx, token, userID, expiresAt)
+}
+
+func (s *sessionStorage) Get(ctx context.Context, token string) (string, error) {
+	defer observeStorage(s.name, "get", time.Now())
+	return s.next.Get(ctx, token)
+}
+
+func (s *sessionStorage) Delete(ctx context.Context, token string) {
+	defer observeStorage(s.name, "delete", time.Now())
+	s.next.Delete(ctx, token)
+}
+
+func (s *sessionStorage) DeleteByUser(ctx context.Context, userID string) {
+	defer observeStorage(s.name, "delete_by_user", time.Now())
+	s.next.DeleteByUser(ctx, userID)
+}
+
+type resetTokenStorage struct {
+	next resettokenstorage.ResetTokenStorageInterface
+}
+
+func NewResetTokenStorage(next resettokenstorage.ResetTokenStorageInterface) resettokenstorage.ResetTokenStorageInterface {
+	return &resetTokenStorage{next: next}
+}
+
+func (s *resetTokenStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	defer observeStorage("reset_token", "add", time.Now())
+	s.next.Add(ctx, token, userID, expiresAt)
+}
+
+func (s *resetTokenStorage) Get(ctx context.Context, token string) (string, error) {
+	defer observeStorage("reset_token", "get", time.Now())
+	return s.next.Get(ctx, token)
+}
+
+func (s *resetTokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	defer observeStorage("reset_token", "delete_by_user", time.Now())
+	s.next.DeleteByUser(ctx, userID)
+}
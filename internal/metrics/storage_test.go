@@ -0,0 +1,73 @@
+package metrics_test
+
+import (
+	"context"
+	"httpserver/internal/metrics"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStorage_ObservesLatency(t *testing.T) {
+	userStorage := metrics.NewUserStorage(userstorage.NewUserStorage())
+	before := sampleCount(t, "user", "get")
+
+	id, _ := userStorage.Add(context.Background(), "JohnDoe", "password123")
+	user, err := userStorage.Get(context.Background(), "JohnDoe")
+
+	assert.NoError(t, err)
+	assert.Equal(t, id, user.Uuid)
+	assert.Equal(t, before+1, sampleCount(t, "user", "get"))
+}
+
+func sampleCount(t *testing.T, storageName string, operation string) uint64 {
+	observer := metrics.StorageOperationDuration.WithLabelValues(storageName, operation)
+
+	var metric dto.Metric
+	err := observer.(prometheus.Metric).Write(&metric)
+	assert.NoError(t, err)
+
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestTokenStorage_DelegatesCalls(t *testing.T) {
+	tokenStorage := metrics.NewTokenStorage(tokenstorage.NewTokenStorage())
+
+	tokenStorage.Add(context.Background(), "abc123", "1")
+	result, err := tokenStorage.Get(context.Background(), "abc123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", result)
+}
+
+func TestActiveUsersStorage_DelegatesCalls(t *testing.T) {
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := metrics.NewActiveUsersStorage(activeuserstorage.NewActiveUsersStorage(users))
+	id, _ := users.Add(context.Background(), "JohnDoe", "password123")
+
+	activeUsersStorage.Add(context.Background(), id)
+	assert.Equal(t, []string{"JohnDoe"}, activeUsersStorage.GetNames(context.Background()))
+
+	activeUsersStorage.Delete(context.Background(), id)
+	_, err := activeUsersStorage.Get(context.Background(), "JohnDoe")
+	assert.Error(t, err)
+}
+
+func TestStreamTokenStorage_ObservesUnderItsOwnName(t *testing.T) {
+	streamTokenStorage := metrics.NewStreamTokenStorage(sessionstorage.NewSessionStorage())
+	before, sessions := sampleCount(t, "stream_token", "get"), sampleCount(t, "session", "get")
+
+	streamTokenStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Minute))
+	_, err := streamTokenStorage.Get(context.Background(), "abc123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, sampleCount(t, "stream_token", "get"))
+	assert.Equal(t, sessions, sampleCount(t, "session", "get"))
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"httpserver/internal/logging"
+	"httpserver/internal/storage"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	NotifierLog  = "log"
+	NotifierFile = "file"
+)
+
+type Notifier interface {
+	NotifyPasswordReset(ctx context.Context, user *storage.User, token string, expiresAt time.Time) error
+}
+
+const redactedToken = "REDACTED"
+
+// LogNotifier logs reset requests without the token, anyone reading the logs could otherwise take over the account.
+// RevealToken logs the token too, for local development where no message is delivered.
+type LogNotifier struct {
+	RevealToken bool
+}
+
+func (n LogNotifier) NotifyPasswordReset(ctx context.Context, user *storage.User, token string, expiresAt time.Time) error {
+	if !n.RevealToken {
+		token = redactedToken
+	}
+	logging.FromContext(ctx).Infow("password reset requested",
+		"user", user.UserName,
+		"token", token,
+		"expires_at", expiresAt,
+	)
+
+	return nil
+}
+
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+type notification struct {
+	Type      string    `json:"type"`
+	UserId    string    `json:"userId"`
+	UserName  string    `json:"userName"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (n *FileNotifier) NotifyPasswordReset(ctx context.Context, user *storage.User, token string, expiresAt time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(notification{
+		Type:      "password_reset",
+		UserId:    user.Uuid,
+		UserName:  user.UserName,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func New(name string, path string, revealTokens bool) (Notifier, error) {
+	switch name {
+	case NotifierLog:
+		return LogNotifier{RevealToken: revealTokens}, nil
+	case NotifierFile:
+		return &FileNotifier{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", name)
+	}
+}
@@ -0,0 +1,71 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"httpserver/internal/logging"
+	"httpserver/internal/notifier"
+	"httpserver/internal/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogNotifier(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := logging.NewContext(context.Background(), zap.New(core).Sugar())
+	user := &storage.User{UserName: "JohnDoe", Uuid: "1"}
+
+	require.NoError(t, notifier.LogNotifier{}.NotifyPasswordReset(ctx, user, "abc123", time.Now()))
+	require.NoError(t, notifier.LogNotifier{RevealToken: true}.NotifyPasswordReset(ctx, user, "def456", time.Now()))
+
+	require.Equal(t, 2, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "password reset requested", entry.Message)
+	assert.Equal(t, "REDACTED", entry.ContextMap()["token"])
+	assert.Equal(t, "JohnDoe", entry.ContextMap()["user"])
+	assert.Equal(t, "def456", logs.All()[1].ContextMap()["token"])
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	fileNotifier := &notifier.FileNotifier{Path: path}
+	user := &storage.User{UserName: "JohnDoe", Uuid: "1"}
+
+	require.NoError(t, fileNotifier.NotifyPasswordReset(context.Background(), user, "abc123", time.Now()))
+	require.NoError(t, fileNotifier.NotifyPasswordReset(context.Background(), user, "def456", time.Now()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	var tokens []string
+	for decoder.More() {
+		var entry map[string]interface{}
+		require.NoError(t, decoder.Decode(&entry))
+		assert.Equal(t, "password_reset", entry["type"])
+		assert.Equal(t, "1", entry["userId"])
+		tokens = append(tokens, entry["token"].(string))
+	}
+	assert.Equal(t, []string{"abc123", "def456"}, tokens)
+}
+
+func TestNew(t *testing.T) {
+	logNotifier, err := notifier.New(notifier.NotifierLog, "", true)
+	assert.NoError(t, err)
+	assert.Equal(t, notifier.LogNotifier{RevealToken: true}, logNotifier)
+
+	fileNotifier, err := notifier.New(notifier.NotifierFile, "notifications.log", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "notifications.log", fileNotifier.(*notifier.FileNotifier).Path)
+
+	_, err = notifier.New("smtp", "", false)
+	assert.Error(t, err)
+}
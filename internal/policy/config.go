@@ -0,0 +1,26 @@
+package policy
+
+import "httpserver/internal/config"
+
+func FromConfig() (*Policy, error) {
+	p := &Policy{
+		UserNameMinLength:      config.GetUserNameMinLength(),
+		UserNameMaxLength:      config.GetUserNameMaxLength(),
+		UserNameAllowedSymbols: config.GetUserNameAllowedSymbols(),
+		ReservedUserNames:      NewSet(config.GetReservedUserNames()),
+		PasswordMinLength:      config.GetPasswordMinLength(),
+		PasswordMaxLength:      config.GetPasswordMaxLength(),
+		PasswordMinClasses:     config.GetPasswordMinCharacterClasses(),
+		PasswordBlocklist:      map[string]struct{}{},
+	}
+
+	if path := config.GetPasswordBlocklistFile(); path != "" {
+		blocklist, err := LoadBlocklist(path)
+		if err != nil {
+			return nil, err
+		}
+		p.PasswordBlocklist = blocklist
+	}
+
+	return p, nil
+}
@@ -0,0 +1,153 @@
+package policy
+
+import (
+	"bufio"
+	"fmt"
+	"httpserver/internal/validation"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RuleReserved   = "reserved"
+	RuleComplexity = "complexity"
+	RuleBlocklist  = "blocklist"
+
+	userNameField = "userName"
+	passwordField = "password"
+)
+
+type Policy struct {
+	UserNameMinLength      int
+	UserNameMaxLength      int
+	UserNameAllowedSymbols string
+	ReservedUserNames      map[string]struct{}
+	PasswordMinLength      int
+	PasswordMaxLength      int
+	PasswordMinClasses     int
+	PasswordBlocklist      map[string]struct{}
+}
+
+func Default() *Policy {
+	return &Policy{
+		UserNameMinLength:      4,
+		UserNameMaxLength:      32,
+		UserNameAllowedSymbols: "._-",
+		ReservedUserNames:      NewSet([]string{"admin", "administrator", "root", "system", "support", "me"}),
+		PasswordMinLength:      8,
+		PasswordMaxLength:      128,
+		PasswordMinClasses:     1,
+		PasswordBlocklist:      map[string]struct{}{},
+	}
+}
+
+func NewSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			set[strings.ToLower(value)] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+func LoadBlocklist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewSet(passwords), nil
+}
+
+func (p *Policy) Validate(userName string, password string) error {
+	errs := append(p.ValidateUserName(userName), p.ValidatePassword(password)...)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *Policy) ValidateUserName(userName string) validation.Errors {
+	var errs validation.Errors
+	length := utf8.RuneCountInString(userName)
+
+	if length < p.UserNameMinLength {
+		errs = append(errs, fieldError(userNameField, validation.RuleMin, "username should be %d chars or longer", p.UserNameMinLength))
+	}
+	if p.UserNameMaxLength > 0 && length > p.UserNameMaxLength {
+		errs = append(errs, fieldError(userNameField, validation.RuleMax, "username should be %d chars or shorter", p.UserNameMaxLength))
+	}
+	if strings.IndexFunc(userName, p.userNameRuneForbidden) >= 0 {
+		errs = append(errs, fieldError(userNameField, validation.RuleCharset, "username may only contain letters, digits and %q", p.UserNameAllowedSymbols))
+	}
+	if _, ok := p.ReservedUserNames[strings.ToLower(userName)]; ok {
+		errs = append(errs, fieldError(userNameField, RuleReserved, "username %s is reserved", userName))
+	}
+
+	return errs
+}
+
+func (p *Policy) ValidatePassword(password string) validation.Errors {
+	var errs validation.Errors
+	length := utf8.RuneCountInString(password)
+
+	if length < p.PasswordMinLength {
+		errs = append(errs, fieldError(passwordField, validation.RuleMin, "password should be %d chars or longer", p.PasswordMinLength))
+	}
+	if p.PasswordMaxLength > 0 && length > p.PasswordMaxLength {
+		errs = append(errs, fieldError(passwordField, validation.RuleMax, "password should be %d chars or shorter", p.PasswordMaxLength))
+	}
+	if classes := characterClasses(password); classes < p.PasswordMinClasses {
+		errs = append(errs, fieldError(passwordField, RuleComplexity, "password should contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.PasswordMinClasses))
+	}
+	if _, ok := p.PasswordBlocklist[strings.ToLower(password)]; ok {
+		errs = append(errs, fieldError(passwordField, RuleBlocklist, "password is too common"))
+	}
+
+	return errs
+}
+
+func (p *Policy) userNameRuneForbidden(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(p.UserNameAllowedSymbols, r)
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+func fieldError(field string, rule string, format string, args ...interface{}) validation.FieldError {
+	return validation.FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
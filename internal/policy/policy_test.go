@@ -0,0 +1,110 @@
+package policy_test
+
+import (
+	"httpserver/internal/policy"
+	"httpserver/internal/validation"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(errs validation.Errors) []string {
+	result := []string{}
+	for _, err := range errs {
+		result = append(result, err.Field+":"+err.Rule)
+	}
+
+	return result
+}
+
+func TestValidateUserName_Valid(t *testing.T) {
+	p := policy.Default()
+
+	assert.Empty(t, p.ValidateUserName("john.doe"))
+	assert.Empty(t, p.ValidateUserName("Jöhn"))
+}
+
+func TestValidateUserName_LengthInRunes(t *testing.T) {
+	p := policy.Default()
+
+	assert.Equal(t, []string{"userName:min"}, rules(p.ValidateUserName("Jöh")))
+	assert.Equal(t, "username should be 4 chars or longer", p.ValidateUserName("Jöh")[0].Message)
+}
+
+func TestValidateUserName_Charset(t *testing.T) {
+	p := policy.Default()
+	p.UserNameAllowedSymbols = ""
+
+	assert.Equal(t, []string{"userName:charset"}, rules(p.ValidateUserName("john.doe")))
+}
+
+func TestValidateUserName_Reserved(t *testing.T) {
+	p := policy.Default()
+
+	assert.Equal(t, []string{"userName:reserved"}, rules(p.ValidateUserName("ROOT")))
+}
+
+func TestValidatePassword_Complexity(t *testing.T) {
+	p := policy.Default()
+	p.PasswordMinClasses = 3
+
+	assert.Equal(t, []string{"password:complexity"}, rules(p.ValidatePassword("password123")))
+	assert.Empty(t, p.ValidatePassword("Password123"))
+}
+
+func TestValidatePassword_Length(t *testing.T) {
+	p := policy.Default()
+	p.PasswordMaxLength = 10
+
+	assert.Equal(t, []string{"password:min"}, rules(p.ValidatePassword("пароль")))
+	assert.Equal(t, []string{"password:max"}, rules(p.ValidatePassword("password12345")))
+}
+
+func TestValidate_AggregatesFields(t *testing.T) {
+	p := policy.Default()
+	p.PasswordBlocklist = policy.NewSet([]string{"Password123"})
+
+	err := p.Validate("me", "PASSWORD123")
+
+	assert.Equal(t, []string{"userName:min", "userName:reserved", "password:blocklist"}, rules(err.(validation.Errors)))
+	assert.NoError(t, p.Validate("JohnDoe", "correct horse battery"))
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	os.WriteFile(path, []byte("# common passwords\n123456\nqwerty\n\nPassword1\n"), 0600)
+
+	blocklist, err := policy.LoadBlocklist(path)
+
+	assert.NoError(t, err)
+	assert.Len(t, blocklist, 3)
+	assert.Contains(t, blocklist, "password1")
+}
+
+func TestLoadBlocklist_MissingFile(t *testing.T) {
+	_, err := policy.LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+
+	assert.Error(t, err)
+}
+
+func TestFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	os.WriteFile(path, []byte("letmein\n"), 0600)
+
+	t.Setenv("USERNAME_MIN_LENGTH", "6")
+	t.Setenv("RESERVED_USERNAMES", "operator, bot")
+	t.Setenv("PASSWORD_MIN_CHARACTER_CLASSES", "2")
+	t.Setenv("PASSWORD_BLOCKLIST_FILE", path)
+
+	p, err := policy.FromConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, p.UserNameMinLength)
+	assert.Equal(t, 8, p.PasswordMinLength)
+	assert.Equal(t, 2, p.PasswordMinClasses)
+	assert.Contains(t, p.ReservedUserNames, "bot")
+	assert.NotContains(t, p.ReservedUserNames, "admin")
+	assert.Contains(t, p.PasswordBlocklist, "letmein")
+}
@@ -0,0 +1,41 @@
+package protocol
+
+import "strings"
+
+const (
+	// EventAuth is the first envelope of a connection opened without credentials.
+	EventAuth = "auth"
+
+	tokenSubprotocolPrefix   = "httpserver.token."
+	sessionSubprotocolPrefix = "httpserver.session."
+)
+
+// AuthPayload carries either the one-time token from the login response or the session token of a previous connection.
+type AuthPayload struct {
+	Token   string `json:"token,omitempty"`
+	Session string `json:"session,omitempty"`
+}
+
+func TokenSubprotocol(token string) string {
+	return tokenSubprotocolPrefix + token
+}
+
+func SessionSubprotocol(session string) string {
+	return sessionSubprotocolPrefix + session
+}
+
+// Credentials reads the token and session offered as Sec-WebSocket-Protocol values, browsers cannot set other headers
+// on a WebSocket handshake. These values are never selected as the subprotocol of the response.
+func Credentials(offered []string) AuthPayload {
+	var credentials AuthPayload
+	for _, subprotocol := range offered {
+		switch {
+		case strings.HasPrefix(subprotocol, tokenSubprotocolPrefix):
+			credentials.Token = strings.TrimPrefix(subprotocol, tokenSubprotocolPrefix)
+		case strings.HasPrefix(subprotocol, sessionSubprotocolPrefix):
+			credentials.Session = strings.TrimPrefix(subprotocol, sessionSubprotocolPrefix)
+		}
+	}
+
+	return credentials
+}
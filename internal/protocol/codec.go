@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Codec encodes envelopes on the wire. Payloads stay JSON inside the server, binary codecs convert them to their
+// own representation so that handlers are independent of the codec.
+type Codec interface {
+	// Name is appended to the protocol version subprotocol, such as httpserver.v1.msgpack.
+	Name() string
+	// Binary codecs are sent in binary frames, the others in text frames.
+	Binary() bool
+	Marshal(envelope Envelope) ([]byte, error)
+	Unmarshal(data []byte) (Envelope, error)
+}
+
+var (
+	JSON     Codec = jsonCodec{}
+	MsgPack  Codec = msgpackCodec{}
+	Protobuf Codec = protobufCodec{}
+
+	// Codecs are listed in the order they are documented, JSON is used when the client names none.
+	Codecs = []Codec{JSON, MsgPack, Protobuf}
+)
+
+func codec(name string) (Codec, bool) {
+	for _, c := range Codecs {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Binary() bool {
+	return false
+}
+
+func (jsonCodec) Marshal(envelope Envelope) ([]byte, error) {
+	return json.Marshal(envelope)
+}
+
+func (jsonCodec) Unmarshal(data []byte) (Envelope, error) {
+	var envelope Envelope
+	err := json.Unmarshal(data, &envelope)
+
+	return envelope, err
+}
+
+// payloadValue converts a JSON payload into plain values for binary codecs. Whole numbers are kept as integers, which
+// MessagePack encodes without losing precision.
+func payloadValue(payload json.RawMessage) (interface{}, error) {
+	if len(payload) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return plainNumbers(value), nil
+}
+
+func plainNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = plainNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	}
+
+	return value
+}
+
+func payloadJSON(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(value)
+}
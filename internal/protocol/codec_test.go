@@ -0,0 +1,121 @@
+package protocol_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/protocol"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	envelopes := []protocol.Envelope{
+		{
+			Type:          "message.sent",
+			ID:            "42",
+			Ts:            time.Date(2023, 3, 1, 12, 30, 0, 123000000, time.UTC),
+			Payload:       json.RawMessage(`{"id":42,"text":"hello","to":null,"tags":["a",1.5,true],"nested":{"ok":false}}`),
+			CorrelationID: "client-1",
+		},
+		{Type: "echo", Ts: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), Payload: json.RawMessage(`"plain"`)},
+		{Type: "ack", Ts: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, codec := range protocol.Codecs {
+		for _, envelope := range envelopes {
+			data, err := codec.Marshal(envelope)
+			require.NoError(t, err, codec.Name())
+
+			decoded, err := codec.Unmarshal(data)
+			require.NoError(t, err, codec.Name())
+			assert.Equal(t, envelope.Type, decoded.Type, codec.Name())
+			assert.Equal(t, envelope.ID, decoded.ID, codec.Name())
+			assert.True(t, envelope.Ts.Equal(decoded.Ts), codec.Name())
+			assert.Equal(t, envelope.CorrelationID, decoded.CorrelationID, codec.Name())
+			if envelope.Payload == nil {
+				assert.Empty(t, decoded.Payload, codec.Name())
+			} else {
+				assert.JSONEq(t, string(envelope.Payload), string(decoded.Payload), codec.Name())
+			}
+		}
+	}
+}
+
+func TestCodecs_BinarySmallerThanJSON(t *testing.T) {
+	envelope, err := protocol.NewEnvelope("message", map[string]interface{}{"from": "user-1", "fromName": "JohnDoe", "text": "hi"})
+	require.NoError(t, err)
+	data, err := protocol.JSON.Marshal(envelope)
+	require.NoError(t, err)
+
+	for _, codec := range []protocol.Codec{protocol.MsgPack, protocol.Protobuf} {
+		binary, err := codec.Marshal(envelope)
+		require.NoError(t, err)
+		assert.True(t, codec.Binary())
+		assert.Less(t, len(binary), len(data), codec.Name())
+	}
+}
+
+func TestProtobuf_TypedPayloads(t *testing.T) {
+	payloads := map[string]string{
+		"message":      `{"from":"user-1","fromName":"JohnDoe","to":"","text":"hi"}`,
+		"presence":     `{"userId":"user-1","userName":"JohnDoe","status":"online"}`,
+		"welcome":      `{"version":1,"versions":[1],"userId":"user-1","sessionToken":"secret","resumed":false}`,
+		"ack":          `{"id":"42"}`,
+		"message.sent": `{"id":9007199254740993,"time":"2023-03-01T12:30:00.123Z"}`,
+		"error":        `{"code":"validation_failed","message":"text is required","fields":[{"field":"text","rule":"required","message":"text is required"}]}`,
+		"warning":      `{"code":"rate_limited","message":"slow down"}`,
+		// Payloads that do not fit the message of their type are sent as a google.protobuf.Value.
+		"presence.extra": `{"userId":"user-1","away":true}`,
+	}
+
+	for eventType, payload := range payloads {
+		envelope := protocol.Envelope{Type: eventType, Ts: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), Payload: json.RawMessage(payload)}
+		data, err := protocol.Protobuf.Marshal(envelope)
+		require.NoError(t, err, eventType)
+
+		decoded, err := protocol.Protobuf.Unmarshal(data)
+		require.NoError(t, err, eventType)
+		assert.JSONEq(t, payload, string(decoded.Payload), eventType)
+	}
+}
+
+func TestProtobuf_KeepsIntegers(t *testing.T) {
+	envelope := protocol.Envelope{Type: "message.sent", Ts: time.Now(), Payload: json.RawMessage(`{"id":9007199254740993,"time":"2023-03-01T12:30:00Z"}`)}
+	data, err := protocol.Protobuf.Marshal(envelope)
+	require.NoError(t, err)
+
+	decoded, err := protocol.Protobuf.Unmarshal(data)
+	require.NoError(t, err)
+	assert.Contains(t, string(decoded.Payload), `"id":9007199254740993`, "a double would round the id")
+}
+
+func TestProtobuf_TypedPayloadSmallerThanJSON(t *testing.T) {
+	envelope, err := protocol.NewEnvelope(protocol.EventWelcome, protocol.WelcomePayload{
+		Version: 1, Versions: []int{1}, UserID: "user-1", SessionToken: "secret",
+	})
+	require.NoError(t, err)
+	data, err := protocol.JSON.Marshal(envelope)
+	require.NoError(t, err)
+
+	binary, err := protocol.Protobuf.Marshal(envelope)
+	require.NoError(t, err)
+	assert.Less(t, len(binary), len(data))
+
+	// A type of the same length without a message of its own is written as a google.protobuf.Value.
+	untyped := envelope
+	untyped.Type = "unknown"
+	value, err := protocol.Protobuf.Marshal(untyped)
+	require.NoError(t, err)
+	assert.Less(t, len(binary), len(value))
+}
+
+func TestDecode_InvalidBinary(t *testing.T) {
+	var protocolErr *protocol.Error
+	for _, codec := range []protocol.Codec{protocol.MsgPack, protocol.Protobuf} {
+		_, err := protocol.Decode(codec, []byte{0xff, 0xff, 0xff})
+		require.ErrorAs(t, err, &protocolErr, codec.Name())
+		assert.Equal(t, protocol.CodeMalformedEnvelope, protocolErr.Code)
+	}
+}
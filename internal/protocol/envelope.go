@@ -0,0 +1,104 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"httpserver/internal/validation"
+	"strings"
+	"time"
+)
+
+const (
+	EventWelcome = "welcome"
+	EventError   = "error"
+	EventWarning = "warning"
+)
+
+const (
+	CodeMalformedEnvelope = "malformed_envelope"
+	CodeUnknownType       = "unknown_type"
+	CodeInvalidPayload    = "invalid_payload"
+	CodeValidationFailed  = "validation_failed"
+	CodeNotFound          = "not_found"
+	CodeInternal          = "internal_error"
+	CodeRateLimited       = "rate_limited"
+	CodeMuted             = "muted"
+)
+
+// Envelope wraps every frame in both directions. Replies and errors carry the id of the client envelope they answer
+// as correlationId.
+type Envelope struct {
+	Type          string          `json:"type"`
+	ID            string          `json:"id,omitempty"`
+	Ts            time.Time       `json:"ts"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+}
+
+// WelcomePayload carries the session token a client passes as the session query parameter to resume after a dropped
+// connection.
+type WelcomePayload struct {
+	Version      int    `json:"version"`
+	Versions     []int  `json:"versions"`
+	UserID       string `json:"userId"`
+	SessionToken string `json:"sessionToken"`
+	Resumed      bool   `json:"resumed"`
+}
+
+// Error is sent as the payload of error and warning events, handlers return it to choose the code.
+type Error struct {
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Fields  []validation.FieldError `json:"fields,omitempty"`
+}
+
+func NewError(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func Decode(codec Codec, data []byte) (Envelope, error) {
+	envelope, err := codec.Unmarshal(data)
+	if err != nil {
+		return Envelope{}, NewError(CodeMalformedEnvelope, "envelope is not a "+codec.Name()+" object")
+	}
+	if strings.TrimSpace(envelope.Type) == "" {
+		return envelope, NewError(CodeMalformedEnvelope, "envelope type is required")
+	}
+
+	return envelope, nil
+}
+
+func NewEnvelope(eventType string, payload interface{}) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{Type: eventType, Ts: time.Now().UTC(), Payload: data}, nil
+}
+
+// ErrorEnvelope answers the envelope with the given id, errors other than *Error are reported as internal errors
+// without their message.
+func ErrorEnvelope(err error, correlationID string) Envelope {
+	var protocolErr *Error
+	if !errors.As(err, &protocolErr) {
+		protocolErr = NewError(CodeInternal, "internal error")
+	}
+
+	envelope, _ := NewEnvelope(EventError, protocolErr)
+	envelope.CorrelationID = correlationID
+
+	return envelope
+}
+
+// WarningEnvelope tells the client the envelope with the given id was dropped without closing the connection.
+func WarningEnvelope(code string, message string, correlationID string) Envelope {
+	envelope, _ := NewEnvelope(EventWarning, NewError(code, message))
+	envelope.CorrelationID = correlationID
+
+	return envelope
+}
@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackEnvelope struct {
+	Type          string      `msgpack:"type"`
+	ID            string      `msgpack:"id,omitempty"`
+	Ts            time.Time   `msgpack:"ts"`
+	Payload       interface{} `msgpack:"payload,omitempty"`
+	CorrelationID string      `msgpack:"correlationId,omitempty"`
+}
+
+// msgpackCodec encodes the envelope as a map with the JSON field names and ts as a MessagePack timestamp.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) Binary() bool {
+	return true
+}
+
+func (msgpackCodec) Marshal(envelope Envelope) ([]byte, error) {
+	payload, err := payloadValue(envelope.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(msgpackEnvelope{
+		Type:          envelope.Type,
+		ID:            envelope.ID,
+		Ts:            envelope.Ts,
+		Payload:       payload,
+		CorrelationID: envelope.CorrelationID,
+	})
+}
+
+func (msgpackCodec) Unmarshal(data []byte) (Envelope, error) {
+	var decoded msgpackEnvelope
+	if err := msgpack.Unmarshal(data, &decoded); err != nil {
+		return Envelope{}, err
+	}
+	payload, err := payloadJSON(decoded.Payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		Type:          decoded.Type,
+		ID:            decoded.ID,
+		Ts:            decoded.Ts,
+		Payload:       payload,
+		CorrelationID: decoded.CorrelationID,
+	}, nil
+}
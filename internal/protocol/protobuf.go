@@ -0,0 +1,458 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	protobufType protowire.Number = iota + 1
+	protobufID
+	protobufTs
+	protobufPayload
+	protobufCorrelationID
+)
+
+var errProtobufEnvelope = errors.New("invalid protobuf envelope")
+
+// protobufCodec writes the Envelope message of envelope.proto with protowire, the message is small enough that
+// generated code would only add a build step. The payloads of the types listed in protobufPayloads are written as their
+// own message, so numbers keep their integer types, the others as google.protobuf.Value.
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string {
+	return "protobuf"
+}
+
+func (protobufCodec) Binary() bool {
+	return true
+}
+
+func (protobufCodec) Marshal(envelope Envelope) ([]byte, error) {
+	var data []byte
+	data = appendProtobufString(data, protobufType, envelope.Type)
+	data = appendProtobufString(data, protobufID, envelope.ID)
+
+	ts, err := proto.Marshal(timestamppb.New(envelope.Ts))
+	if err != nil {
+		return nil, err
+	}
+	data = protowire.AppendTag(data, protobufTs, protowire.BytesType)
+	data = protowire.AppendBytes(data, ts)
+
+	if typed, ok := protobufPayloads[envelope.Type]; ok && len(envelope.Payload) > 0 {
+		if encoded, ok := typed.marshal(envelope.Payload); ok {
+			data = protowire.AppendTag(data, typed.number, protowire.BytesType)
+			data = protowire.AppendBytes(data, encoded)
+
+			return appendProtobufString(data, protobufCorrelationID, envelope.CorrelationID), nil
+		}
+	}
+	if len(envelope.Payload) > 0 {
+		value, err := payloadValue(envelope.Payload)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := structpb.NewValue(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := proto.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		data = protowire.AppendTag(data, protobufPayload, protowire.BytesType)
+		data = protowire.AppendBytes(data, encoded)
+	}
+
+	return appendProtobufString(data, protobufCorrelationID, envelope.CorrelationID), nil
+}
+
+func appendProtobufString(data []byte, number protowire.Number, value string) []byte {
+	if value == "" {
+		return data
+	}
+	data = protowire.AppendTag(data, number, protowire.BytesType)
+
+	return protowire.AppendString(data, value)
+}
+
+func (protobufCodec) Unmarshal(data []byte) (Envelope, error) {
+	var envelope Envelope
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return Envelope{}, errProtobufEnvelope
+		}
+		data = data[n:]
+
+		if wireType != protowire.BytesType {
+			// Unknown fields are skipped so that newer clients can add them.
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+			if n < 0 {
+				return Envelope{}, errProtobufEnvelope
+			}
+			data = data[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return Envelope{}, errProtobufEnvelope
+		}
+		data = data[n:]
+
+		switch number {
+		case protobufType:
+			envelope.Type = string(value)
+		case protobufID:
+			envelope.ID = string(value)
+		case protobufCorrelationID:
+			envelope.CorrelationID = string(value)
+		case protobufTs:
+			var ts timestamppb.Timestamp
+			if err := proto.Unmarshal(value, &ts); err != nil {
+				return Envelope{}, err
+			}
+			envelope.Ts = ts.AsTime()
+		case protobufPayload:
+			var payload structpb.Value
+			if err := proto.Unmarshal(value, &payload); err != nil {
+				return Envelope{}, err
+			}
+			encoded, err := payloadJSON(payload.AsInterface())
+			if err != nil {
+				return Envelope{}, err
+			}
+			envelope.Payload = encoded
+		default:
+			if typed, ok := protobufPayloadByNumber(number); ok {
+				payload, err := typed.unmarshal(value)
+				if err != nil {
+					return Envelope{}, err
+				}
+				envelope.Payload = payload
+			}
+		}
+	}
+
+	return envelope, nil
+}
+
+type protobufKind int
+
+const (
+	protobufKindString protobufKind = iota
+	protobufKindBool
+	protobufKindInt
+	protobufKindUint
+	protobufKindInts
+	protobufKindTime
+	protobufKindMessages
+)
+
+// protobufField maps a JSON payload field to a field of a payload message of envelope.proto.
+type protobufField struct {
+	number protowire.Number
+	name   string
+	kind   protobufKind
+	// fields of the repeated message of a protobufKindMessages field.
+	fields []protobufField
+}
+
+// protobufMessage is the message a typed payload is written as, in the Envelope field number.
+type protobufMessage struct {
+	number protowire.Number
+	fields []protobufField
+}
+
+var protobufErrorPayload = protobufMessage{number: 11, fields: []protobufField{
+	{number: 1, name: "code"},
+	{number: 2, name: "message"},
+	{number: 3, name: "fields", kind: protobufKindMessages, fields: []protobufField{
+		{number: 1, name: "field"},
+		{number: 2, name: "rule"},
+		{number: 3, name: "message"},
+	}},
+}}
+
+// protobufPayloads lists the event types written with their own message instead of google.protobuf.Value.
+var protobufPayloads = map[string]protobufMessage{
+	"message": {number: 6, fields: []protobufField{
+		{number: 1, name: "from"},
+		{number: 2, name: "fromName"},
+		{number: 3, name: "to"},
+		{number: 4, name: "text"},
+	}},
+	"presence": {number: 7, fields: []protobufField{
+		{number: 1, name: "userId"},
+		{number: 2, name: "userName"},
+		{number: 3, name: "status"},
+	}},
+	EventWelcome: {number: 8, fields: []protobufField{
+		{number: 1, name: "version", kind: protobufKindInt},
+		{number: 2, name: "versions", kind: protobufKindInts},
+		{number: 3, name: "userId"},
+		{number: 4, name: "sessionToken"},
+		{number: 5, name: "resumed", kind: protobufKindBool},
+	}},
+	"ack": {number: 9, fields: []protobufField{
+		{number: 1, name: "id"},
+	}},
+	"message.sent": {number: 10, fields: []protobufField{
+		{number: 1, name: "id", kind: protobufKindUint},
+		{number: 2, name: "time", kind: protobufKindTime},
+	}},
+	EventError:   protobufErrorPayload,
+	EventWarning: protobufErrorPayload,
+}
+
+func protobufPayloadByNumber(number protowire.Number) (protobufMessage, bool) {
+	for _, payload := range protobufPayloads {
+		if payload.number == number {
+			return payload, true
+		}
+	}
+
+	return protobufMessage{}, false
+}
+
+// marshal writes a JSON payload as the message of its type, it reports false when the payload does not fit, such as
+// an unknown field or a value of another type, so the caller falls back to google.protobuf.Value.
+func (p protobufMessage) marshal(payload json.RawMessage) ([]byte, bool) {
+	value, err := payloadValue(payload)
+	object, ok := value.(map[string]interface{})
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	return appendProtobufObject(nil, p.fields, object)
+}
+
+func (p protobufMessage) unmarshal(data []byte) (json.RawMessage, error) {
+	object, err := consumeProtobufObject(p.fields, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(object)
+}
+
+func appendProtobufObject(data []byte, fields []protobufField, object map[string]interface{}) ([]byte, bool) {
+	for name := range object {
+		if _, ok := protobufFieldByName(fields, name); !ok {
+			return nil, false
+		}
+	}
+
+	for _, field := range fields {
+		value, ok := object[field.name]
+		if !ok {
+			continue
+		}
+		if data, ok = field.append(data, value); !ok {
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+func (f protobufField) append(data []byte, value interface{}) ([]byte, bool) {
+	switch f.kind {
+	case protobufKindString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		data = protowire.AppendTag(data, f.number, protowire.BytesType)
+		return protowire.AppendString(data, s), true
+	case protobufKindBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, false
+		}
+		data = protowire.AppendTag(data, f.number, protowire.VarintType)
+		return protowire.AppendVarint(data, protowire.EncodeBool(b)), true
+	case protobufKindInt, protobufKindUint:
+		i, ok := value.(int64)
+		if !ok || (f.kind == protobufKindUint && i < 0) {
+			return nil, false
+		}
+		data = protowire.AppendTag(data, f.number, protowire.VarintType)
+		return protowire.AppendVarint(data, uint64(i)), true
+	case protobufKindInts:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		var packed []byte
+		for _, item := range items {
+			i, ok := item.(int64)
+			if !ok {
+				return nil, false
+			}
+			packed = protowire.AppendVarint(packed, uint64(i))
+		}
+		// An empty list is still written so that it decodes as [] rather than a missing field.
+		data = protowire.AppendTag(data, f.number, protowire.BytesType)
+		return protowire.AppendBytes(data, packed), true
+	case protobufKindTime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, false
+		}
+		ts, err := proto.Marshal(timestamppb.New(t))
+		if err != nil {
+			return nil, false
+		}
+		data = protowire.AppendTag(data, f.number, protowire.BytesType)
+		return protowire.AppendBytes(data, ts), true
+	case protobufKindMessages:
+		// An empty list of messages cannot be told apart from a missing field.
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			return nil, false
+		}
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			encoded, ok := appendProtobufObject(nil, f.fields, object)
+			if !ok {
+				return nil, false
+			}
+			data = protowire.AppendTag(data, f.number, protowire.BytesType)
+			data = protowire.AppendBytes(data, encoded)
+		}
+		return data, true
+	}
+
+	return nil, false
+}
+
+func consumeProtobufObject(fields []protobufField, data []byte) (map[string]interface{}, error) {
+	object := map[string]interface{}{}
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errProtobufEnvelope
+		}
+		data = data[n:]
+
+		field, ok := protobufFieldByNumber(fields, number)
+		if ok {
+			n = field.consume(object, wireType, data)
+		} else {
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+		}
+		if n < 0 {
+			return nil, errProtobufEnvelope
+		}
+		data = data[n:]
+	}
+
+	return object, nil
+}
+
+// consume reads the value of the field into object and returns its length, or a negative length when it is invalid.
+func (f protobufField) consume(object map[string]interface{}, wireType protowire.Type, data []byte) int {
+	switch f.kind {
+	case protobufKindBool, protobufKindInt, protobufKindUint:
+		if wireType != protowire.VarintType {
+			return -1
+		}
+		v, n := protowire.ConsumeVarint(data)
+		switch f.kind {
+		case protobufKindBool:
+			object[f.name] = protowire.DecodeBool(v)
+		case protobufKindInt:
+			object[f.name] = int64(int32(v))
+		default:
+			object[f.name] = v
+		}
+		return n
+	case protobufKindInts:
+		items, _ := object[f.name].([]interface{})
+		if items == nil {
+			items = []interface{}{}
+		}
+		if wireType == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(data)
+			object[f.name] = append(items, int64(int32(v)))
+			return n
+		}
+	}
+
+	if wireType != protowire.BytesType {
+		return -1
+	}
+	value, n := protowire.ConsumeBytes(data)
+	if n < 0 {
+		return n
+	}
+
+	switch f.kind {
+	case protobufKindString:
+		object[f.name] = string(value)
+	case protobufKindInts:
+		items, _ := object[f.name].([]interface{})
+		if items == nil {
+			items = []interface{}{}
+		}
+		for len(value) > 0 {
+			v, m := protowire.ConsumeVarint(value)
+			if m < 0 {
+				return m
+			}
+			items = append(items, int64(int32(v)))
+			value = value[m:]
+		}
+		object[f.name] = items
+	case protobufKindTime:
+		var ts timestamppb.Timestamp
+		if err := proto.Unmarshal(value, &ts); err != nil {
+			return -1
+		}
+		object[f.name] = ts.AsTime().Format(time.RFC3339Nano)
+	case protobufKindMessages:
+		item, err := consumeProtobufObject(f.fields, value)
+		if err != nil {
+			return -1
+		}
+		items, _ := object[f.name].([]interface{})
+		object[f.name] = append(items, item)
+	}
+
+	return n
+}
+
+func protobufFieldByName(fields []protobufField, name string) (protobufField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+
+	return protobufField{}, false
+}
+
+func protobufFieldByNumber(fields []protobufField, number protowire.Number) (protobufField, bool) {
+	for _, field := range fields {
+		if field.number == number {
+			return field, true
+		}
+	}
+
+	return protobufField{}, false
+}
@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	Version1       = 1
+	CurrentVersion = Version1
+
+	subprotocolPrefix = "httpserver.v"
+)
+
+var (
+	SupportedVersions = []int{Version1}
+
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+)
+
+func Subprotocol(version int) string {
+	return subprotocolPrefix + strconv.Itoa(version)
+}
+
+// Negotiate picks the newest supported version among the offered Sec-WebSocket-Protocol values, and the codec named
+// by the first offer of that version. Clients that offer no protocol version get the current one encoded as JSON
+// without a subprotocol in the response.
+func Negotiate(offered []string) (int, Codec, string, error) {
+	version, selected, found := 0, "", false
+	for _, subprotocol := range offered {
+		if !strings.HasPrefix(subprotocol, subprotocolPrefix) {
+			continue
+		}
+		found = true
+
+		candidate, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(subprotocol, subprotocolPrefix), ".", 2)[0])
+		if err != nil || !supported(candidate) || candidate <= version {
+			continue
+		}
+		if _, ok := subprotocolCodec(subprotocol); ok {
+			version, selected = candidate, subprotocol
+		}
+	}
+
+	switch {
+	case !found:
+		return CurrentVersion, JSON, "", nil
+	case version == 0:
+		return 0, nil, "", ErrUnsupportedVersion
+	}
+	codec, _ := subprotocolCodec(selected)
+
+	return version, codec, selected, nil
+}
+
+// subprotocolCodec reads the codec from a subprotocol such as httpserver.v1.msgpack, a bare version means JSON.
+func subprotocolCodec(subprotocol string) (Codec, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(subprotocol, subprotocolPrefix), ".", 2)
+	if len(parts) == 1 {
+		return JSON, true
+	}
+
+	return codec(parts[1])
+}
+
+// CodecSubprotocol names a version encoded with a codec other than JSON.
+func CodecSubprotocol(version int, codec Codec) string {
+	if codec == JSON {
+		return Subprotocol(version)
+	}
+
+	return Subprotocol(version) + "." + codec.Name()
+}
+
+func supported(version int) bool {
+	for _, v := range SupportedVersions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"httpserver/internal/protocol"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		offered     []string
+		version     int
+		codec       protocol.Codec
+		subprotocol string
+		err         error
+	}{
+		{nil, protocol.CurrentVersion, protocol.JSON, "", nil},
+		{[]string{"other"}, protocol.CurrentVersion, protocol.JSON, "", nil},
+		{[]string{"other", "httpserver.v1"}, protocol.Version1, protocol.JSON, "httpserver.v1", nil},
+		{[]string{"httpserver.v99", "httpserver.v1"}, protocol.Version1, protocol.JSON, "httpserver.v1", nil},
+		{[]string{"httpserver.v99"}, 0, nil, "", protocol.ErrUnsupportedVersion},
+		{[]string{"httpserver.vx"}, 0, nil, "", protocol.ErrUnsupportedVersion},
+		{[]string{"httpserver.token.abc", "httpserver.v1"}, protocol.Version1, protocol.JSON, "httpserver.v1", nil},
+		{[]string{"httpserver.v1.msgpack", "httpserver.v1"}, protocol.Version1, protocol.MsgPack, "httpserver.v1.msgpack", nil},
+		{[]string{"httpserver.v1.protobuf", "httpserver.v1.msgpack"}, protocol.Version1, protocol.Protobuf, "httpserver.v1.protobuf", nil},
+		{[]string{"httpserver.v1.xml", "httpserver.v1"}, protocol.Version1, protocol.JSON, "httpserver.v1", nil},
+		{[]string{"httpserver.v1.xml"}, 0, nil, "", protocol.ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		version, codec, subprotocol, err := protocol.Negotiate(tt.offered)
+		assert.Equal(t, tt.version, version, tt.offered)
+		assert.Equal(t, tt.codec, codec, tt.offered)
+		assert.Equal(t, tt.subprotocol, subprotocol, tt.offered)
+		assert.Equal(t, tt.err, err, tt.offered)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	assert.Equal(t, protocol.AuthPayload{}, protocol.Credentials([]string{"httpserver.v1"}))
+
+	credentials := protocol.Credentials([]string{"httpserver.v1", protocol.TokenSubprotocol("abc"), protocol.SessionSubprotocol("def")})
+	assert.Equal(t, protocol.AuthPayload{Token: "abc", Session: "def"}, credentials)
+}
+
+func TestDecode(t *testing.T) {
+	envelope, err := protocol.Decode(protocol.JSON, []byte(`{"type":"echo","id":"1","payload":{"a":1}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "echo", envelope.Type)
+	assert.Equal(t, "1", envelope.ID)
+	assert.JSONEq(t, `{"a":1}`, string(envelope.Payload))
+
+	var protocolErr *protocol.Error
+	_, err = protocol.Decode(protocol.JSON, []byte(`[]`))
+	require.ErrorAs(t, err, &protocolErr)
+	assert.Equal(t, protocol.CodeMalformedEnvelope, protocolErr.Code)
+
+	_, err = protocol.Decode(protocol.JSON, []byte(`{"id":"1"}`))
+	require.ErrorAs(t, err, &protocolErr)
+	assert.Equal(t, protocol.CodeMalformedEnvelope, protocolErr.Code)
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	envelope := protocol.ErrorEnvelope(protocol.NewError(protocol.CodeNotFound, "missing"), "7")
+	assert.Equal(t, protocol.EventError, envelope.Type)
+	assert.Equal(t, "7", envelope.CorrelationID)
+	assert.JSONEq(t, `{"code":"not_found","message":"missing"}`, string(envelope.Payload))
+
+	envelope = protocol.ErrorEnvelope(errors.New("database is down"), "")
+	assert.JSONEq(t, `{"code":"internal_error","message":"internal error"}`, string(envelope.Payload))
+}
+
+func TestWarningEnvelope(t *testing.T) {
+	envelope := protocol.WarningEnvelope(protocol.CodeRateLimited, "slow down", "8")
+	assert.Equal(t, protocol.EventWarning, envelope.Type)
+	assert.Equal(t, "8", envelope.CorrelationID)
+	assert.JSONEq(t, `{"code":"rate_limited","message":"slow down"}`, string(envelope.Payload))
+}
+
+type greeting struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestRegistry_Dispatch(t *testing.T) {
+	registry := protocol.NewRegistry()
+	protocol.Handle(registry, "greet", "greeted", func(ctx context.Context, payload greeting) (interface{}, error) {
+		return map[string]string{"text": "hello " + payload.Name}, nil
+	})
+	protocol.Handle(registry, "ignore", "", func(ctx context.Context, payload greeting) (interface{}, error) {
+		return nil, nil
+	})
+	assert.True(t, registry.Has("greet"))
+	assert.False(t, registry.Has("dance"))
+
+	reply, err := registry.Dispatch(context.Background(), protocol.Envelope{Type: "greet", ID: "1", Payload: []byte(`{"name":"John"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "greeted", reply.Type)
+	assert.Equal(t, "1", reply.CorrelationID)
+	assert.False(t, reply.Ts.IsZero())
+	assert.JSONEq(t, `{"text":"hello John"}`, string(reply.Payload))
+
+	reply, err = registry.Dispatch(context.Background(), protocol.Envelope{Type: "ignore", Payload: []byte(`{"name":"John"}`)})
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+}
+
+func TestRegistry_DispatchErrors(t *testing.T) {
+	registry := protocol.NewRegistry()
+	protocol.Handle(registry, "greet", "greeted", func(ctx context.Context, payload greeting) (interface{}, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		envelope protocol.Envelope
+		code     string
+	}{
+		{protocol.Envelope{Type: "dance"}, protocol.CodeUnknownType},
+		{protocol.Envelope{Type: "greet", Payload: []byte(`"John"`)}, protocol.CodeInvalidPayload},
+		{protocol.Envelope{Type: "greet", Payload: []byte(`{"name":"John","age":3}`)}, protocol.CodeInvalidPayload},
+		{protocol.Envelope{Type: "greet"}, protocol.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		_, err := registry.Dispatch(context.Background(), tt.envelope)
+
+		var protocolErr *protocol.Error
+		require.ErrorAs(t, err, &protocolErr)
+		assert.Equal(t, tt.code, protocolErr.Code, tt.envelope)
+	}
+}
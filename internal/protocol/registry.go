@@ -0,0 +1,93 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"httpserver/internal/validation"
+)
+
+type envelopeKey struct{}
+
+// EnvelopeFromContext returns the envelope being dispatched, handlers use it to read the client message id.
+func EnvelopeFromContext(ctx context.Context) (Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeKey{}).(Envelope)
+	return envelope, ok
+}
+
+type Handler func(ctx context.Context, envelope Envelope) (interface{}, error)
+
+type route struct {
+	replyType string
+	handler   Handler
+}
+
+type Registry struct {
+	routes map[string]route
+}
+
+func NewRegistry() *Registry {
+	return &Registry{routes: map[string]route{}}
+}
+
+// Handle registers f for eventType. The payload is decoded into T and validated before f is called, a non-nil
+// result is sent back as a replyType envelope correlated to the request.
+func Handle[T any](registry *Registry, eventType string, replyType string, f func(ctx context.Context, payload T) (interface{}, error)) {
+	registry.routes[eventType] = route{
+		replyType: replyType,
+		handler: func(ctx context.Context, envelope Envelope) (interface{}, error) {
+			var payload T
+			if err := decodePayload(envelope.Payload, &payload); err != nil {
+				return nil, err
+			}
+
+			return f(ctx, payload)
+		},
+	}
+}
+
+func (r *Registry) Has(eventType string) bool {
+	_, ok := r.routes[eventType]
+	return ok
+}
+
+// Dispatch runs the handler registered for the envelope type and returns the reply to send, if any.
+func (r *Registry) Dispatch(ctx context.Context, envelope Envelope) (*Envelope, error) {
+	route, ok := r.routes[envelope.Type]
+	if !ok {
+		return nil, NewError(CodeUnknownType, "unknown event type "+envelope.Type)
+	}
+
+	result, err := route.handler(context.WithValue(ctx, envelopeKey{}, envelope), envelope)
+	if err != nil || result == nil {
+		return nil, err
+	}
+
+	reply, err := NewEnvelope(route.replyType, result)
+	if err != nil {
+		return nil, err
+	}
+	reply.CorrelationID = envelope.ID
+
+	return &reply, nil
+}
+
+func decodePayload(data json.RawMessage, dst interface{}) error {
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return NewError(CodeInvalidPayload, "payload does not match the event type")
+	}
+
+	var errs validation.Errors
+	if err := validation.Validate(dst); errors.As(err, &errs) {
+		return &Error{Code: CodeValidationFailed, Message: errs.Error(), Fields: errs}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PubSub relays messages between the instances of the server. A message published on a channel is handed to every
+// handler subscribed to that channel on any instance, the publishing one included. Every message of a channel gets the
+// next id of that channel, and handlers receive the messages in the order of their ids.
+type PubSub interface {
+	Publish(ctx context.Context, channel string, message []byte) (id uint64, err error)
+	// Subscribe calls handler for every message of the channel until the returned function is called.
+	Subscribe(channel string, handler Handler) (unsubscribe func(), err error)
+	Close() error
+}
+
+// Handler receives the messages of a channel one at a time, it must not publish on the channel itself.
+type Handler func(id uint64, message []byte)
+
+// Memory relays messages between the hubs of a single process, it is enough when only one instance runs.
+type Memory struct {
+	// publishing keeps the messages in the order of their ids while the handlers are called.
+	publishing sync.Mutex
+	mu         sync.Mutex
+	handlers   map[string]map[*subscriber]struct{}
+	lastIDs    map[string]uint64
+}
+
+type subscriber struct {
+	handler Handler
+}
+
+func NewMemory() *Memory {
+	return &Memory{handlers: map[string]map[*subscriber]struct{}{}, lastIDs: map[string]uint64{}}
+}
+
+// Publish calls the handlers synchronously, in no particular order.
+func (m *Memory) Publish(ctx context.Context, channel string, message []byte) (uint64, error) {
+	m.publishing.Lock()
+	defer m.publishing.Unlock()
+
+	m.mu.Lock()
+	m.lastIDs[channel]++
+	id := m.lastIDs[channel]
+	subscribers := make([]*subscriber, 0, len(m.handlers[channel]))
+	for s := range m.handlers[channel] {
+		subscribers = append(subscribers, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.handler(id, message)
+	}
+
+	return id, nil
+}
+
+func (m *Memory) Subscribe(channel string, handler Handler) (func(), error) {
+	s := &subscriber{handler: handler}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.handlers[channel] == nil {
+		m.handlers[channel] = map[*subscriber]struct{}{}
+	}
+	m.handlers[channel][s] = struct{}{}
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.handlers[channel], s)
+		if len(m.handlers[channel]) == 0 {
+			delete(m.handlers, channel)
+		}
+	}, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// publishScript gives the message the next id of the channel and publishes it in the same step, so the server sends
+// the messages of a channel in the order of their ids.
+var publishScript = redis.NewScript(`
+local id = redis.call("INCR", KEYS[1])
+redis.call("PUBLISH", KEYS[2], id .. " " .. ARGV[1])
+return id
+`)
+
+// Redis relays messages with PUBLISH and SUBSCRIBE, every instance connected to the same server receives them. The
+// last id of a channel is kept under the channel name with an :id suffix.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Publish(ctx context.Context, channel string, message []byte) (uint64, error) {
+	id, err := publishScript.Run(ctx, r.client, []string{channel + ":id", channel}, message).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(id), nil
+}
+
+// Subscribe returns once the server confirmed the subscription, so messages published afterwards are not missed. When
+// the connection is lost later on it is opened again, messages published in the meantime are lost.
+func (r *Redis) Subscribe(channel string, handler Handler) (func(), error) {
+	ctx := context.Background()
+	subscription := r.client.Subscribe(ctx, channel)
+	if _, err := subscription.Receive(ctx); err != nil {
+		subscription.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for message := range subscription.Channel() {
+			id, payload, err := parseMessage(message.Payload)
+			if err != nil {
+				// Only messages published through Publish carry an id.
+				continue
+			}
+			handler(id, payload)
+		}
+	}()
+
+	return func() {
+		subscription.Close()
+		<-done
+	}, nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func parseMessage(message string) (uint64, []byte, error) {
+	id, payload, ok := strings.Cut(message, " ")
+	if !ok {
+		return 0, nil, fmt.Errorf("message %q has no id", message)
+	}
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return parsed, []byte(payload), nil
+}
+
+// New connects to the Redis server of the redis:// or rediss:// url, or relays in memory when url is empty.
+func New(ctx context.Context, url string) (PubSub, error) {
+	if url == "" {
+		return NewMemory(), nil
+	}
+
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return NewRedis(client), nil
+}
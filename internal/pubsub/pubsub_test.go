@@ -0,0 +1,115 @@
+package pubsub_test
+
+import (
+	"context"
+	"fmt"
+	"httpserver/internal/pubsub"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(messages chan string) pubsub.Handler {
+	return func(id uint64, message []byte) {
+		messages <- fmt.Sprintf("%d %s", id, message)
+	}
+}
+
+func receive(t *testing.T, messages chan string) string {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("message was not received")
+		return ""
+	}
+}
+
+func TestMemory_PublishAndUnsubscribe(t *testing.T) {
+	ps := pubsub.NewMemory()
+	first, second, other := make(chan string, 1), make(chan string, 1), make(chan string, 1)
+	unsubscribe, err := ps.Subscribe("events", collect(first))
+	require.NoError(t, err)
+	_, err = ps.Subscribe("events", collect(second))
+	require.NoError(t, err)
+	_, err = ps.Subscribe("other", collect(other))
+	require.NoError(t, err)
+
+	id, err := ps.Publish(context.Background(), "events", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), id)
+	assert.Equal(t, "1 hello", receive(t, first))
+	assert.Equal(t, "1 hello", receive(t, second))
+	assert.Empty(t, other)
+
+	unsubscribe()
+	_, err = ps.Publish(context.Background(), "events", []byte("again"))
+	require.NoError(t, err)
+	assert.Equal(t, "2 again", receive(t, second))
+	assert.Empty(t, first)
+
+	id, err = ps.Publish(context.Background(), "other", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), id, "Every channel should count its own ids")
+}
+
+func TestRedis_PublishReachesEveryClient(t *testing.T) {
+	server := miniredis.RunT(t)
+	first, err := pubsub.New(context.Background(), "redis://"+server.Addr())
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := pubsub.New(context.Background(), "redis://"+server.Addr())
+	require.NoError(t, err)
+	defer second.Close()
+
+	firstMessages, secondMessages := make(chan string, 1), make(chan string, 1)
+	stop, err := first.Subscribe("events", collect(firstMessages))
+	require.NoError(t, err)
+	defer stop()
+	unsubscribe, err := second.Subscribe("events", collect(secondMessages))
+	require.NoError(t, err)
+
+	id, err := first.Publish(context.Background(), "events", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), id)
+	assert.Equal(t, "1 hello", receive(t, firstMessages))
+	assert.Equal(t, "1 hello", receive(t, secondMessages))
+
+	unsubscribe()
+	id, err = second.Publish(context.Background(), "events", []byte("again"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), id, "Ids should be shared by every client of the server")
+	assert.Equal(t, "2 again", receive(t, firstMessages))
+	assert.Empty(t, secondMessages)
+}
+
+func TestRedis_SubscribeReturnsError(t *testing.T) {
+	server := miniredis.RunT(t)
+	ps, err := pubsub.New(context.Background(), "redis://"+server.Addr())
+	require.NoError(t, err)
+	defer ps.Close()
+	server.Close()
+
+	_, err = ps.Subscribe("events", func(id uint64, message []byte) {})
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	ps, err := pubsub.New(context.Background(), "")
+	require.NoError(t, err)
+	assert.IsType(t, &pubsub.Memory{}, ps)
+
+	_, err = pubsub.New(context.Background(), "http://localhost")
+	assert.Error(t, err)
+
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+	_, err = pubsub.New(context.Background(), "redis://"+addr)
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultKey names the rate applied to keys without a rate of their own in ParseRates.
+const DefaultKey = "*"
+
+// Rate allows Burst events at once and refills PerSecond events every second.
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+// ParseRates reads a comma separated list of key=perSecond:burst pairs, such as "*=10:20,message=2:5".
+func ParseRates(value string) (map[string]Rate, error) {
+	rates := map[string]Rate{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, spec, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate %q should be key=perSecond:burst", pair)
+		}
+		perSecond, burst, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("rate %q should be key=perSecond:burst", pair)
+		}
+		rate := Rate{}
+		var err error
+		if rate.PerSecond, err = strconv.ParseFloat(perSecond, 64); err != nil || rate.PerSecond <= 0 {
+			return nil, fmt.Errorf("rate %q should have a positive number of events per second", pair)
+		}
+		if rate.Burst, err = strconv.Atoi(burst); err != nil || rate.Burst <= 0 {
+			return nil, fmt.Errorf("rate %q should have a positive burst", pair)
+		}
+		rates[strings.TrimSpace(key)] = rate
+	}
+
+	return rates, nil
+}
+
+// Bucket is a token bucket, it is not safe for concurrent use. The caller passes the current time so that a fake
+// clock can drive it.
+type Bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+func NewBucket(rate Rate, now time.Time) *Bucket {
+	return &Bucket{rate: rate, tokens: float64(rate.Burst), last: now}
+}
+
+// Allow takes a token if one is left.
+func (b *Bucket) Allow(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.rate.Burst), b.tokens+elapsed.Seconds()*b.rate.PerSecond)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	return true
+}
@@ -0,0 +1,44 @@
+package ratelimit_test
+
+import (
+	"httpserver/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucket_Allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	bucket := ratelimit.NewBucket(ratelimit.Rate{PerSecond: 2, Burst: 3}, now)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow(now), "Burst should be allowed at once")
+	}
+	assert.False(t, bucket.Allow(now))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, bucket.Allow(now), "One token should be refilled after half a second")
+	assert.False(t, bucket.Allow(now))
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.Allow(now))
+	}
+	assert.False(t, bucket.Allow(now), "Refill should be capped at the burst")
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ratelimit.ParseRates("*=10:20, message=0.5:2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ratelimit.Rate{
+		ratelimit.DefaultKey: {PerSecond: 10, Burst: 20},
+		"message":            {PerSecond: 0.5, Burst: 2},
+	}, rates)
+
+	for _, value := range []string{"message", "message=1", "message=x:1", "message=1:0", "message=-1:1"} {
+		_, err := ratelimit.ParseRates(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,5 @@
+package requests
+
+type AckRequest struct {
+	Id string `json:"id" label:"id" validate:"required,max=20"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+type MessageRequest struct {
+	Text string `json:"text" label:"text" validate:"required,max=4096"`
+	To   string `json:"to,omitempty" label:"recipient" validate:"max=64"`
+}
+
+func (MessageRequest) MaxBodySize() int64 {
+	return 16 << 10
+}
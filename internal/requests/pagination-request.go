@@ -0,0 +1,30 @@
+package requests
+
+import "net/http"
+
+const DefaultPageLimit = 50
+
+type PaginationRequest struct {
+	Offset int `json:"offset" label:"offset" validate:"min=0"`
+	Limit  int `json:"limit" label:"limit" validate:"min=1,max=100"`
+}
+
+func DecodePagination(request *http.Request) (PaginationRequest, error) {
+	page := PaginationRequest{Limit: DefaultPageLimit}
+	err := DecodeQuery(request, &page)
+
+	return page, err
+}
+
+type ActiveUserListRequest struct {
+	Limit  int    `json:"limit" label:"limit" validate:"min=1,max=100"`
+	Cursor string `json:"cursor" label:"cursor" validate:"max=256"`
+	Prefix string `json:"prefix" label:"prefix" validate:"max=64"`
+}
+
+func DecodeActiveUserList(request *http.Request) (ActiveUserListRequest, error) {
+	list := ActiveUserListRequest{Limit: DefaultPageLimit}
+	err := DecodeQuery(request, &list)
+
+	return list, err
+}
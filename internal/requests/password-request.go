@@ -0,0 +1,27 @@
+package requests
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword" label:"current password" validate:"required"`
+	NewPassword     string `json:"newPassword" label:"new password" validate:"required"`
+}
+
+func (PasswordChangeRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
+
+type PasswordResetRequest struct {
+	UserName string `json:"userName" label:"username" validate:"required"`
+}
+
+func (PasswordResetRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" label:"reset token" validate:"required"`
+	NewPassword string `json:"newPassword" label:"new password" validate:"required"`
+}
+
+func (PasswordResetConfirmRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
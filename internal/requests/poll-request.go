@@ -0,0 +1,14 @@
+package requests
+
+import "net/http"
+
+type PollRequest struct {
+	Since int `json:"since" label:"since" validate:"min=0"`
+}
+
+func DecodePoll(request *http.Request) (PollRequest, error) {
+	var poll PollRequest
+	err := DecodeQuery(request, &poll)
+
+	return poll, err
+}
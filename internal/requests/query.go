@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"httpserver/internal/validation"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+const RuleInteger = "integer"
+
+// DecodeQuery fills the string and int fields of dst from query parameters named after their json tags,
+// fields without a parameter keep their current value so callers can preset defaults.
+func DecodeQuery(request *http.Request, dst interface{}) error {
+	query := request.URL.Query()
+	value := reflect.ValueOf(dst).Elem()
+	var errs validation.Errors
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := validation.FieldName(field)
+		raw, ok := query[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			value.Field(i).SetString(raw[0])
+		case reflect.Int:
+			number, err := strconv.Atoi(raw[0])
+			if err != nil {
+				errs = append(errs, validation.FieldError{Field: name, Rule: RuleInteger, Message: label(field) + " should be an integer"})
+				continue
+			}
+			value.Field(i).SetInt(int64(number))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return validation.Validate(dst)
+}
+
+func label(field reflect.StructField) string {
+	if label := field.Tag.Get("label"); label != "" {
+		return label
+	}
+
+	return validation.FieldName(field)
+}
@@ -0,0 +1,81 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"httpserver/internal/validation"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const DefaultMaxBodySize int64 = 1 << 20
+
+var (
+	ErrInvalidBody  = errors.New("invalid body")
+	ErrBodyTooLarge = errors.New("body too large")
+)
+
+type bodyLimiter interface {
+	MaxBodySize() int64
+}
+
+func Decode(request *http.Request, dst interface{}) error {
+	maxBodySize := DefaultMaxBodySize
+	if limiter, ok := dst.(bodyLimiter); ok {
+		maxBodySize = limiter.MaxBodySize()
+	}
+
+	body := &limitedReader{reader: request.Body, remaining: maxBodySize}
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		if body.exceeded {
+			return ErrBodyTooLarge
+		}
+		if field := unknownField(err); field != "" {
+			return validation.Errors{{Field: field, Rule: validation.RuleUnknown, Message: "unknown field " + field}}
+		}
+		return ErrInvalidBody
+	}
+	if decoder.More() {
+		return ErrInvalidBody
+	}
+
+	return validation.Validate(dst)
+}
+
+func unknownField(err error) string {
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return ""
+	}
+
+	return strings.Trim(strings.TrimPrefix(err.Error(), prefix), `"`)
+}
+
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		l.exceeded = true
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		l.exceeded = true
+		return n, ErrBodyTooLarge
+	}
+
+	return n, err
+}
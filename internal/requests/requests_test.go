@@ -0,0 +1,126 @@
+package requests_test
+
+import (
+	"httpserver/internal/requests"
+	"httpserver/internal/validation"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(body))
+}
+
+func TestDecode_UserRequest(t *testing.T) {
+	var body requests.UserRequest
+
+	err := requests.Decode(newRequest(`{"userName": "JohnDoe", "password": "password123"}`), &body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, requests.UserRequest{UserName: "JohnDoe", Password: "password123"}, body)
+}
+
+func TestDecode_InvalidJSON(t *testing.T) {
+	var body requests.UserRequest
+
+	err := requests.Decode(newRequest(`{"userName": "JohnDoe"`), &body)
+
+	assert.ErrorIs(t, err, requests.ErrInvalidBody)
+}
+
+func TestDecode_NonStringField(t *testing.T) {
+	var body requests.UserRequest
+
+	err := requests.Decode(newRequest(`{"userName": 42, "password": "password123"}`), &body)
+
+	assert.ErrorIs(t, err, requests.ErrInvalidBody)
+}
+
+func TestDecode_TrailingData(t *testing.T) {
+	var body requests.UserRequest
+
+	err := requests.Decode(newRequest(`{"userName": "JohnDoe", "password": "password123"} {}`), &body)
+
+	assert.ErrorIs(t, err, requests.ErrInvalidBody)
+}
+
+func TestDecode_UnknownField(t *testing.T) {
+	var body requests.UserRequest
+
+	err := requests.Decode(newRequest(`{"userName": "JohnDoe", "password": "password123", "role": "admin"}`), &body)
+
+	assert.Equal(t, validation.Errors{{Field: "role", Rule: "unknown", Message: "unknown field role"}}, err)
+}
+
+func TestDecode_BodyTooLarge(t *testing.T) {
+	var body requests.UserLoginRequest
+
+	err := requests.Decode(newRequest(`{"userName": "JohnDoe", "password": "`+strings.Repeat("a", 5000)+`"}`), &body)
+
+	assert.ErrorIs(t, err, requests.ErrBodyTooLarge)
+}
+
+func TestDecode_DefaultBodyLimit(t *testing.T) {
+	var body map[string]string
+
+	err := requests.Decode(newRequest(`{"text": "`+strings.Repeat("a", int(requests.DefaultMaxBodySize))+`"}`), &body)
+
+	assert.ErrorIs(t, err, requests.ErrBodyTooLarge)
+}
+
+func TestDecode_ValidationErrors(t *testing.T) {
+	var body requests.UserLoginRequest
+
+	err := requests.Decode(newRequest(`{"password": ""}`), &body)
+
+	assert.Equal(t, validation.Errors{
+		{Field: "userName", Rule: "required", Message: "username is required"},
+		{Field: "password", Rule: "required", Message: "password is required"},
+	}, err)
+}
+
+func TestDecodePagination(t *testing.T) {
+	page, err := requests.DecodePagination(httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, requests.PaginationRequest{Offset: 0, Limit: requests.DefaultPageLimit}, page)
+
+	page, err = requests.DecodePagination(httptest.NewRequest(http.MethodGet, "/admin/users?offset=10&limit=5", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, requests.PaginationRequest{Offset: 10, Limit: 5}, page)
+}
+
+func TestDecodePagination_Invalid(t *testing.T) {
+	_, err := requests.DecodePagination(httptest.NewRequest(http.MethodGet, "/admin/users?offset=abc&limit=500", nil))
+
+	var errs validation.Errors
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, validation.Errors{
+		{Field: "offset", Rule: requests.RuleInteger, Message: "offset should be an integer"},
+	}, errs)
+
+	_, err = requests.DecodePagination(httptest.NewRequest(http.MethodGet, "/admin/users?offset=-1&limit=500", nil))
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, []string{"offset", "limit"}, []string{errs[0].Field, errs[1].Field})
+}
+
+func TestDecodeActiveUserList(t *testing.T) {
+	list, err := requests.DecodeActiveUserList(httptest.NewRequest(http.MethodGet, "/user/active/list?prefix=jo&cursor=abc", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, requests.ActiveUserListRequest{Limit: requests.DefaultPageLimit, Cursor: "abc", Prefix: "jo"}, list)
+
+	_, err = requests.DecodeActiveUserList(httptest.NewRequest(http.MethodGet, "/user/active/list?limit=x", nil))
+	assert.EqualError(t, err, "limit should be an integer")
+}
+
+func TestDecodePoll(t *testing.T) {
+	poll, err := requests.DecodePoll(httptest.NewRequest(http.MethodGet, "/poll?since=42", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, requests.PollRequest{Since: 42}, poll)
+
+	_, err = requests.DecodePoll(httptest.NewRequest(http.MethodGet, "/poll?since=-1", nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package requests
+
+const credentialsMaxBodySize int64 = 4 << 10
+
+type UserRequest struct {
+	UserName string `json:"userName" label:"username" validate:"required"`
+	Password string `json:"password" label:"password" validate:"required"`
+}
+
+func (UserRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
+
+// UserLoginRequest has no length rules, the policy may have changed since the user registered.
+type UserLoginRequest struct {
+	UserName string `json:"userName" label:"username" validate:"required"`
+	Password string `json:"password" label:"password" validate:"required"`
+}
+
+func (UserLoginRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
@@ -0,0 +1,13 @@
+package requests
+
+type UserUpdateRequest struct {
+	UserName    *string `json:"userName,omitempty" label:"username"`
+	DisplayName *string `json:"displayName,omitempty" label:"display name" validate:"max=64"`
+	AvatarUrl   *string `json:"avatarUrl,omitempty" label:"avatar URL" validate:"max=2048,url"`
+	Bio         *string `json:"bio,omitempty" label:"bio" validate:"max=500"`
+	StatusText  *string `json:"statusText,omitempty" label:"status text" validate:"max=140"`
+}
+
+func (UserUpdateRequest) MaxBodySize() int64 {
+	return credentialsMaxBodySize
+}
@@ -0,0 +1,28 @@
+package responses
+
+import (
+	"httpserver/internal/storage/activeuserstorage"
+	"time"
+)
+
+type ActiveUserResponse struct {
+	Id          string    `json:"id"`
+	UserName    string    `json:"userName"`
+	DisplayName string    `json:"displayName"`
+	OnlineSince time.Time `json:"onlineSince"`
+}
+
+func NewActiveUserResponse(activeUser activeuserstorage.ActiveUser) ActiveUserResponse {
+	return ActiveUserResponse{
+		Id:          activeUser.User.Uuid,
+		UserName:    activeUser.User.UserName,
+		DisplayName: activeUser.User.DisplayName,
+		OnlineSince: activeUser.OnlineSince,
+	}
+}
+
+type ActiveUserListResponse struct {
+	Users      []ActiveUserResponse `json:"users"`
+	Total      int                  `json:"total"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,43 @@
+package responses
+
+import (
+	"httpserver/internal/storage"
+	"time"
+)
+
+type AdminUserResponse struct {
+	Id          string   `json:"id"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName"`
+	Roles       []string `json:"roles"`
+	Banned      bool     `json:"banned"`
+}
+
+func NewAdminUserResponse(user *storage.User) AdminUserResponse {
+	roles := user.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	return AdminUserResponse{
+		Id:          user.Uuid,
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		Roles:       roles,
+		Banned:      user.Banned,
+	}
+}
+
+type AdminUserListResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int                 `json:"total"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+}
+
+type ActiveSessionResponse struct {
+	Id          string    `json:"id"`
+	UserName    string    `json:"userName"`
+	OnlineSince time.Time `json:"onlineSince"`
+	Connections int       `json:"connections"`
+}
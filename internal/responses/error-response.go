@@ -0,0 +1,8 @@
+package responses
+
+import "httpserver/internal/validation"
+
+type ErrorResponse struct {
+	Error  string                  `json:"error"`
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
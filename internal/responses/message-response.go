@@ -0,0 +1,8 @@
+package responses
+
+import "time"
+
+type MessageResponse struct {
+	Id   uint64    `json:"id"`
+	Time time.Time `json:"time"`
+}
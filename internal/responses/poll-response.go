@@ -0,0 +1,8 @@
+package responses
+
+import "httpserver/internal/hub"
+
+type PollResponse struct {
+	Events      []hub.Event `json:"events"`
+	LastEventId uint64      `json:"lastEventId"`
+}
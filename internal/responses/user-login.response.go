@@ -1,5 +1,7 @@
 package responses
 
 type UserLoginResponse struct {
-	Url string `json:"url"`
+	Url     string `json:"url"`
+	WsToken string `json:"wsToken"`
+	Token   string `json:"token"`
 }
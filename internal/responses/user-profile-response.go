@@ -0,0 +1,23 @@
+package responses
+
+import "httpserver/internal/storage"
+
+type UserProfileResponse struct {
+	Id          string `json:"id"`
+	UserName    string `json:"userName"`
+	DisplayName string `json:"displayName"`
+	AvatarUrl   string `json:"avatarUrl"`
+	Bio         string `json:"bio"`
+	StatusText  string `json:"statusText"`
+}
+
+func NewUserProfileResponse(user *storage.User) UserProfileResponse {
+	return UserProfileResponse{
+		Id:          user.Uuid,
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		AvatarUrl:   user.AvatarUrl,
+		Bio:         user.Bio,
+		StatusText:  user.StatusText,
+	}
+}
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type activeUserPage struct {
+	Users []struct {
+		Id          string    `json:"id"`
+		UserName    string    `json:"userName"`
+		OnlineSince time.Time `json:"onlineSince"`
+	} `json:"users"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor"`
+}
+
+func getActiveUsers(t *testing.T, url string) activeUserPage {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page activeUserPage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+
+	return page
+}
+
+func TestServer_ActiveUserListPagination(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	for _, name := range []string{"Charlie", "Alice", "Bobby", "Alfred"} {
+		postJSON(t, apiUrl+"/user", `{"userName":"`+name+`","password":"password123"}`)
+		wsToken, _ := loginSession(t, apiUrl, name, "password123")
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+	}
+
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, apiUrl+"/user/active/list").Total == 4
+	}, time.Second, 10*time.Millisecond)
+
+	page := getActiveUsers(t, apiUrl+"/user/active/list?limit=3")
+	require.Len(t, page.Users, 3)
+	assert.Equal(t, []string{"Alfred", "Alice", "Bobby"}, []string{page.Users[0].UserName, page.Users[1].UserName, page.Users[2].UserName})
+	assert.NotEmpty(t, page.Users[0].Id)
+	assert.False(t, page.Users[0].OnlineSince.IsZero())
+	require.NotEmpty(t, page.NextCursor)
+
+	page = getActiveUsers(t, apiUrl+"/user/active/list?limit=3&cursor="+page.NextCursor)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, "Charlie", page.Users[0].UserName)
+	assert.Empty(t, page.NextCursor)
+
+	page = getActiveUsers(t, apiUrl+"/user/active/list?prefix=al")
+	assert.Equal(t, 2, page.Total)
+	assert.Len(t, page.Users, 2)
+
+	resp, err := http.Get(apiUrl + "/user/active/list?cursor=!!!")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(testServer.URL + "/user/active/list")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var names []string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&names))
+	assert.Len(t, names, 4, "legacy route keeps returning bare names")
+}
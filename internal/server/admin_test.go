@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/server"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_AdminRequiresPermission(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp := authorized(t, http.MethodGet, apiUrl+"/admin/users", token, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err := http.Get(apiUrl + "/admin/users")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_AdminListUsers(t *testing.T) {
+	testServer := newTestServer(t, server.WithAdmin("root", "rootpassword"))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	postJSON(t, apiUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "root", "rootpassword")
+
+	resp := authorized(t, http.MethodGet, apiUrl+"/admin/users?offset=1&limit=1", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page struct {
+		Users []struct {
+			UserName string   `json:"userName"`
+			Roles    []string `json:"roles"`
+		} `json:"users"`
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	assert.Equal(t, 3, page.Total)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, "JohnDoe", page.Users[0].UserName)
+	assert.Empty(t, page.Users[0].Roles)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/admin/users?limit=0", token, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_AdminBanDisconnectsUser(t *testing.T) {
+	testServer := newTestServer(t, server.WithAdmin("root", "rootpassword"))
+	apiUrl := testServer.URL + "/api/v1"
+
+	resp := postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	wsToken, userToken := loginSession(t, apiUrl, "JohnDoe", "password123")
+	_, adminToken := loginSession(t, apiUrl, "root", "rootpassword")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var sessions []map[string]interface{}
+	assert.Eventually(t, func() bool {
+		resp := authorized(t, http.MethodGet, apiUrl+"/admin/sessions", adminToken, nil)
+		sessions = nil
+		json.NewDecoder(resp.Body).Decode(&sessions)
+		return len(sessions) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, created["id"], sessions[0]["id"])
+	assert.Equal(t, float64(1), sessions[0]["connections"])
+
+	resp = authorized(t, http.MethodPut, apiUrl+"/admin/users/"+created["id"]+"/ban", adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assertClosed(t, conn)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", userToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = postJSON(t, apiUrl+"/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authorized(t, http.MethodDelete, apiUrl+"/admin/users/"+created["id"]+"/ban", adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	loginSession(t, apiUrl, "JohnDoe", "password123")
+}
+
+func TestServer_AdminDisconnectUser(t *testing.T) {
+	testServer := newTestServer(t, server.WithAdmin("root", "rootpassword"))
+	apiUrl := testServer.URL + "/api/v1"
+
+	resp := postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	wsToken, userToken := loginSession(t, apiUrl, "JohnDoe", "password123")
+	_, adminToken := loginSession(t, apiUrl, "root", "rootpassword")
+
+	resp = authorized(t, http.MethodDelete, apiUrl+"/admin/users/"+created["id"]+"/connections", adminToken, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool {
+		resp := authorized(t, http.MethodDelete, apiUrl+"/admin/users/"+created["id"]+"/connections", adminToken, nil)
+		return resp.StatusCode == http.StatusNoContent
+	}, time.Second, 10*time.Millisecond)
+
+	assertClosed(t, conn)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", userToken, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "disconnecting does not revoke the session")
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"httpserver/internal/apidoc"
+	"httpserver/internal/controller"
+	"httpserver/internal/hub"
+	"httpserver/internal/protocol"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"net/http"
+)
+
+const (
+	websocketChannel = "ws"
+	eventsChannel    = "events"
+)
+
+func (s *Server) asyncAPIDocument() *apidoc.AsyncAPI {
+	doc := apidoc.NewAsyncAPI(apiTitle, apiVersion)
+
+	doc.AddChannel(websocketChannel, &apidoc.Channel{
+		Address: s.versions[0].Prefix() + "/ws",
+		Description: "WebSocket connection opened with the one-time token from the login response. Also served at /ws during migration. " +
+			"Offer the token as the " + protocol.TokenSubprotocol("<token>") + " Sec-WebSocket-Protocol value, or the session to " +
+			"resume as " + protocol.SessionSubprotocol("<session>") + ", next to the protocol version. Clients that cannot " +
+			"do so connect without credentials and send an auth envelope first, the connection is closed with code 1008 " +
+			"when it does not arrive within a few seconds or is rejected. The token and session query parameters are " +
+			"deprecated, they end up in access logs. Browsers may only connect from the origin serving the API or an allowed " +
+			"origin. When enabled on the server, permessage-deflate is negotiated with clients that offer it and applied to " +
+			"messages above a size threshold. " +
+			"Every frame is an envelope with type, id, ts, payload and correlationId. The protocol version is negotiated with " +
+			"Sec-WebSocket-Protocol " + protocol.Subprotocol(protocol.Version1) + ", clients that offer none get the current version. " +
+			"Offer " + protocol.CodecSubprotocol(protocol.Version1, protocol.MsgPack) + " or " +
+			protocol.CodecSubprotocol(protocol.Version1, protocol.Protobuf) + " to exchange envelopes as MessagePack maps with " +
+			"the same fields, or as the Envelope message of internal/protocol/envelope.proto, in binary frames. The protobuf " +
+			"Envelope carries the payloads of welcome, message, presence, ack, message.sent, error and warning as their own " +
+			"messages and the other payloads, such as echo, as a google.protobuf.Value. " +
+			"Events with an id are sent again after a reconnect until acknowledged with ack, to resume reconnect offering the " +
+			"sessionToken of the welcome event as " + protocol.SessionSubprotocol("<session>") + ", or in the auth envelope, " +
+			"instead of a new token. Event ids are the same on " +
+			"every instance, sessions are kept by the instance serving the connection and resume on that instance. Messages sent again with " +
+			"the same id are published once. A client that falls behind on events is closed with " +
+			"code 1013, resuming the session delivers the events it missed. Frames over the maximum message size close the " +
+			"connection with code 1009.",
+		Bindings: map[string]apidoc.WsBinding{
+			"ws": {
+				Method: http.MethodGet,
+				Query: &apidoc.Schema{
+					Type: "object",
+					Properties: map[string]*apidoc.Schema{
+						"token":   {Type: "string", Description: "Deprecated, one-time token from the login response"},
+						"session": {Type: "string", Description: "Deprecated, session token of a previous connection to resume"},
+					},
+				},
+				Headers: &apidoc.Schema{
+					Type:       "object",
+					Properties: map[string]*apidoc.Schema{"Sec-WebSocket-Protocol": {Type: "string"}},
+				},
+			},
+		},
+	})
+
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    protocol.EventWelcome,
+		Summary: "First envelope of every connection with the negotiated protocol version",
+		Payload: envelopeSchema(protocol.EventWelcome, doc.SchemaRef(protocol.WelcomePayload{})),
+	}, apidoc.ActionSend, "Server confirms the connection")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    protocol.EventAuth,
+		Summary: "First envelope of a connection opened without credentials, with either token or session set",
+		Payload: envelopeSchema(protocol.EventAuth, doc.SchemaRef(protocol.AuthPayload{})),
+	}, apidoc.ActionReceive, "Client authenticates the connection")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    protocol.EventError,
+		Summary: "Envelope could not be handled, correlationId is the id of the offending envelope",
+		Payload: envelopeSchema(protocol.EventError, doc.SchemaRef(protocol.Error{})),
+	}, apidoc.ActionSend, "Server rejects an envelope")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name: protocol.EventWarning,
+		Summary: "Envelope was dropped for exceeding the rate of its type, code is rate_limited, or muted when every envelope " +
+			"is dropped for a while. Exceeding the rate again after being muted closes the connection with code 1008",
+		Payload: envelopeSchema(protocol.EventWarning, doc.SchemaRef(protocol.Error{})),
+	}, apidoc.ActionSend, "Server warns about a rate limit")
+
+	echo := apidoc.Message{
+		Name:    "echo",
+		Summary: "Payload sent back unchanged to the same connection",
+		Payload: envelopeSchema("echo", &apidoc.Schema{}),
+	}
+	doc.AddMessage(websocketChannel, echo, apidoc.ActionReceive, "Client sends an echo event")
+	doc.AddMessage(websocketChannel, echo, apidoc.ActionSend, "Server echoes the payload back")
+
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    controller.EventAck,
+		Summary: "Acknowledges every event up to and including id, they are no longer sent again on resume",
+		Payload: envelopeSchema(controller.EventAck, doc.SchemaRef(requests.AckRequest{})),
+	}, apidoc.ActionReceive, "Client acknowledges received events")
+
+	messageData := doc.SchemaRef(hub.MessageData{})
+	presenceData := doc.SchemaRef(hub.PresenceData{})
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    "messageRequest",
+		Summary: "Chat message, broadcast when to is empty, otherwise delivered to the recipient and the sender",
+		Payload: envelopeSchema(hub.EventMessage, doc.SchemaRef(requests.MessageRequest{})),
+	}, apidoc.ActionReceive, "Client sends a chat message")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    "messageSent",
+		Summary: "Id assigned to a chat message sent by this connection",
+		Payload: envelopeSchema(controller.EventMessageSent, doc.SchemaRef(responses.MessageResponse{})),
+	}, apidoc.ActionSend, "Server acknowledges a chat message")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    hub.EventMessage,
+		Summary: "Chat message published on the event bus",
+		Payload: envelopeSchema(hub.EventMessage, messageData),
+	}, apidoc.ActionSend, "Server delivers a chat message")
+	doc.AddMessage(websocketChannel, apidoc.Message{
+		Name:    hub.EventPresence,
+		Summary: "A user came online or went offline",
+		Payload: envelopeSchema(hub.EventPresence, presenceData),
+	}, apidoc.ActionSend, "Server announces presence changes")
+
+	doc.AddChannel(eventsChannel, &apidoc.Channel{
+		Address: s.versions[0].Prefix() + "/events",
+		Description: "Server-Sent Events stream carrying the same events as the WebSocket. Each event has id, event (the type) " +
+			"and data (the event data) fields. Authenticate with a session bearer token, or with the token query parameter set " +
+			"to a stream token from POST /events/token, which EventSource can reuse when it reconnects, or to the one-time login " +
+			"token. Resume with the Last-Event-ID header or lastEventId query parameter. Send messages with POST /messages.",
+	})
+	doc.AddMessage(eventsChannel, apidoc.Message{
+		Name:    "sseMessage",
+		Summary: "Chat message, sent as an event of type message",
+		Payload: messageData,
+	}, apidoc.ActionSend, "Server streams a chat message")
+	doc.AddMessage(eventsChannel, apidoc.Message{
+		Name:    "ssePresence",
+		Summary: "A user came online or went offline, sent as an event of type presence",
+		Payload: presenceData,
+	}, apidoc.ActionSend, "Server streams a presence change")
+
+	return doc
+}
+
+func envelopeSchema(eventType string, payload *apidoc.Schema) *apidoc.Schema {
+	return &apidoc.Schema{
+		Type: "object",
+		Properties: map[string]*apidoc.Schema{
+			"type":          {Type: "string", Const: eventType},
+			"id":            {Type: "string"},
+			"ts":            {Type: "string", Format: "date-time"},
+			"payload":       payload,
+			"correlationId": {Type: "string"},
+		},
+		Required: []string{"type", "ts"},
+	}
+}
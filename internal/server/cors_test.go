@@ -0,0 +1,48 @@
+package server_test
+
+import (
+	"httpserver/internal/server"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CorsPreflight(t *testing.T) {
+	testServer := newTestServer(t, server.WithAllowedOrigins("https://app.example.com"))
+
+	req, err := http.NewRequest(http.MethodOptions, testServer.URL+"/api/v1/user/login", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestServer_WebsocketChecksOrigin(t *testing.T) {
+	testServer := newTestServer(t, server.WithAllowedOrigins("https://app.example.com"))
+	apiUrl := testServer.URL + "/api/v1"
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	wsUrl := "ws" + apiUrl[4:] + "/ws?token="
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsUrl+login(t, apiUrl, "JohnDoe", "password123"), http.Header{"Origin": {"https://evil.example.com"}})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsUrl+login(t, apiUrl, "JohnDoe", "password123"), http.Header{"Origin": {"https://app.example.com"}})
+	require.NoError(t, err)
+	conn.Close()
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type envelope struct {
+	Type          string          `json:"type"`
+	ID            string          `json:"id"`
+	Payload       json.RawMessage `json:"payload"`
+	CorrelationID string          `json:"correlationId"`
+}
+
+func dialWs(t *testing.T, url string) (*websocket.Conn, map[string]interface{}) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	welcome := readUntil(t, conn, "welcome")
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(welcome.Payload, &payload))
+
+	return conn, payload
+}
+
+// readUntil returns the next envelope of the given type, skipping the others.
+func readUntil(t *testing.T, conn *websocket.Conn, eventType string) envelope {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for {
+		var e envelope
+		require.NoError(t, conn.ReadJSON(&e))
+		if e.Type == eventType {
+			return e
+		}
+	}
+}
+
+func messageText(t *testing.T, e envelope) string {
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(e.Payload, &data))
+
+	return data["text"]
+}
+
+func TestServer_WebsocketResumesSessionWithUnackedMessages(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+	wsUrl := "ws" + apiUrl[4:] + "/ws"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	postJSON(t, apiUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+	johnToken, _ := loginSession(t, apiUrl, "JohnDoe", "password123")
+	_, janeSession := loginSession(t, apiUrl, "JaneDoe", "password123")
+
+	conn, welcome := dialWs(t, wsUrl+"?token="+johnToken)
+	sessionToken := welcome["sessionToken"].(string)
+	assert.NotEmpty(t, sessionToken)
+	assert.Equal(t, false, welcome["resumed"])
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/messages", janeSession, strings.NewReader(`{"text":"first"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	first := readUntil(t, conn, "message")
+	assert.Equal(t, "first", messageText(t, first))
+	conn.Close()
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/messages", janeSession, strings.NewReader(`{"text":"second"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	conn, welcome = dialWs(t, wsUrl+"?session="+sessionToken)
+	assert.Equal(t, true, welcome["resumed"])
+	retransmitted := readUntil(t, conn, "message")
+	assert.Equal(t, first.ID, retransmitted.ID)
+	second := readUntil(t, conn, "message")
+	assert.Equal(t, "second", messageText(t, second))
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "ack", "payload": map[string]string{"id": second.ID}}))
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "echo", "payload": "barrier"}))
+	readUntil(t, conn, "echo")
+	conn.Close()
+
+	conn, _ = dialWs(t, wsUrl+"?session="+sessionToken)
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "echo", "payload": "barrier"}))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for {
+		var e envelope
+		require.NoError(t, conn.ReadJSON(&e))
+		require.NotEqual(t, "message", e.Type, "acknowledged message sent again")
+		if e.Type == "echo" {
+			break
+		}
+	}
+}
+
+func TestServer_WebsocketRejectsUnknownSession(t *testing.T) {
+	testServer := newTestServer(t)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+testServer.URL[4:]+"/api/v1/ws?session=unknown", nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_WebsocketDeduplicatesClientMessages(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token := login(t, apiUrl, "JohnDoe", "password123")
+	conn, _ := dialWs(t, "ws"+apiUrl[4:]+"/ws?token="+token)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "message", "id": "client-1", "payload": map[string]string{"text": "once"}}))
+	}
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "message", "id": "client-2", "payload": map[string]string{"text": "barrier"}}))
+
+	var acknowledged []string
+	var texts []string
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for len(acknowledged) < 2 || len(texts) == 0 || texts[len(texts)-1] != "barrier" {
+		var e envelope
+		require.NoError(t, conn.ReadJSON(&e))
+		switch {
+		case e.Type == "message.sent" && e.CorrelationID == "client-1":
+			acknowledged = append(acknowledged, string(e.Payload))
+		case e.Type == "message":
+			texts = append(texts, messageText(t, e))
+		}
+	}
+	assert.Equal(t, acknowledged[0], acknowledged[1])
+	assert.Equal(t, []string{"once", "barrier"}, texts)
+}
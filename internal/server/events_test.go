@@ -0,0 +1,190 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+func openEvents(t *testing.T, url string, token string, lastEventID string) *bufio.Reader {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent skips comments and retry fields and returns the next event of the given type.
+func readEvent(t *testing.T, reader *bufio.Reader, eventType string) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.Event == eventType {
+				return event
+			}
+			event = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestServer_EventsRequireAuthentication(t *testing.T) {
+	testServer := newTestServer(t)
+
+	resp, err := http.Get(testServer.URL + "/api/v1/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_EventsStreamMessagesAndResume(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	events := openEvents(t, apiUrl+"/events", token, "")
+	presence := readEvent(t, events, "presence")
+	assert.Contains(t, presence.Data, `"status":"online"`)
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/messages", token, strings.NewReader(`{"text":"first"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	first := readEvent(t, events, "message")
+	assert.Contains(t, first.Data, `"text":"first"`)
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/messages", token, strings.NewReader(`{"text":"second"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resumed := openEvents(t, apiUrl+"/events", token, first.ID)
+	second := readEvent(t, resumed, "message")
+	assert.Contains(t, second.Data, `"text":"second"`)
+}
+
+func TestServer_EventsReconnectWithStreamToken(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	wsToken, session := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/events/token", session, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotEmpty(t, body["token"])
+	require.NotEmpty(t, body["expiresAt"])
+
+	// EventSource cannot send headers and reconnects with the URL it was created with.
+	eventsUrl := apiUrl + "/events?token=" + body["token"]
+	events := openEvents(t, eventsUrl, "", "")
+	readEvent(t, events, "presence")
+	resp = authorized(t, http.MethodPost, apiUrl+"/messages", session, strings.NewReader(`{"text":"first"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	first := readEvent(t, events, "message")
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/messages", session, strings.NewReader(`{"text":"second"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resumed := openEvents(t, eventsUrl, "", first.ID)
+	assert.Contains(t, readEvent(t, resumed, "message").Data, `"text":"second"`)
+
+	// The one-time login token is still accepted once.
+	openEvents(t, apiUrl+"/events?token="+wsToken, "", "")
+	resp, err := http.Get(apiUrl + "/events?token=" + wsToken)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_MessagesShareHubAcrossTransports(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	postJSON(t, apiUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+	_, johnSession := loginSession(t, apiUrl, "JohnDoe", "password123")
+	janeToken, _ := loginSession(t, apiUrl, "JaneDoe", "password123")
+
+	events := openEvents(t, apiUrl+"/events", johnSession, "")
+	readEvent(t, events, "presence")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+janeToken, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "message", "payload": map[string]string{"text": "hello"}}))
+	message := readEvent(t, events, "message")
+
+	var data map[string]string
+	require.NoError(t, json.Unmarshal([]byte(message.Data), &data))
+	assert.Equal(t, "JaneDoe", data["fromName"])
+	assert.Equal(t, "hello", data["text"])
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/messages", johnSession, strings.NewReader(`{"text":"hi"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for {
+		var envelope struct {
+			Type    string                 `json:"type"`
+			ID      string                 `json:"id"`
+			Payload map[string]interface{} `json:"payload"`
+		}
+		require.NoError(t, conn.ReadJSON(&envelope))
+		if envelope.Type == "message" && envelope.Payload["text"] == "hi" {
+			assert.Equal(t, "JohnDoe", envelope.Payload["fromName"])
+			break
+		}
+	}
+}
+
+func TestServer_MessagesUnknownRecipient(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/messages", token, strings.NewReader(`{"text":"hi","to":"missing"}`))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/messages", token, strings.NewReader(`{"text":""}`))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
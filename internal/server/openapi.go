@@ -0,0 +1,403 @@
+package server
+
+import (
+	"httpserver/internal/apidoc"
+	"httpserver/internal/requests"
+	"httpserver/internal/responses"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiTitle   = "httpserver"
+	apiVersion = "1.0.0"
+)
+
+func (s *Server) openAPIDocument() *apidoc.OpenAPI {
+	doc := apidoc.NewOpenAPI(apiTitle, apiVersion)
+
+	doc.AddOperation(http.MethodGet, "/metrics", &apidoc.Operation{
+		OperationID: "getMetrics",
+		Summary:     "Prometheus metrics in text exposition format",
+		Tags:        []string{"meta"},
+		Responses:   map[string]apidoc.Response{"200": {Description: "Metrics", Content: apidoc.TextContent()}},
+	})
+	doc.AddOperation(http.MethodGet, "/openapi.json", &apidoc.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "This OpenAPI document",
+		Tags:        []string{"meta"},
+		Responses:   map[string]apidoc.Response{"200": {Description: "OpenAPI document", Content: apidoc.JSONContent(&apidoc.Schema{Type: "object"})}},
+	})
+	doc.AddOperation(http.MethodGet, "/asyncapi.json", &apidoc.Operation{
+		OperationID: "getAsyncAPI",
+		Summary:     "AsyncAPI document describing WebSocket events",
+		Tags:        []string{"meta"},
+		Responses:   map[string]apidoc.Response{"200": {Description: "AsyncAPI document", Content: apidoc.JSONContent(&apidoc.Schema{Type: "object"})}},
+	})
+
+	for _, version := range s.versions {
+		if version.Document != nil {
+			version.Document(doc, version.Prefix(), !version.Deprecation.IsZero())
+		}
+	}
+	documentLegacy(doc, "", true)
+
+	return doc
+}
+
+func documentV1(doc *apidoc.OpenAPI, prefix string, deprecated bool) {
+	documentShared(doc, prefix, deprecated)
+
+	doc.AddOperation(http.MethodGet, prefix+"/user/active/list", &apidoc.Operation{
+		OperationID: operationID("listActiveUsers", prefix),
+		Summary:     "List users with an open WebSocket connection ordered by username",
+		Tags:        []string{"user"},
+		Deprecated:  deprecated,
+		Parameters: []apidoc.Parameter{
+			{Name: "limit", In: "query", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(100)}},
+			{Name: "cursor", In: "query", Description: "nextCursor of the previous page", Schema: &apidoc.Schema{Type: "string"}},
+			{Name: "prefix", In: "query", Description: "Case-insensitive username prefix", Schema: &apidoc.Schema{Type: "string", MaxLength: intPtr(64)}},
+		},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Page of active users", Content: apidoc.JSONContent(doc.SchemaRef(responses.ActiveUserListResponse{}))},
+			"400": {Description: "Invalid query parameters", Content: apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))},
+		},
+	})
+
+	doc.Components.SecuritySchemes["bearer"] = apidoc.SecurityScheme{Type: "http", Scheme: "bearer"}
+	bearer := []map[string][]string{{"bearer": {}}}
+	profile := apidoc.JSONContent(doc.SchemaRef(responses.UserProfileResponse{}))
+	errorContent := apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))
+	unauthorized := apidoc.Response{Description: "Missing or invalid session token", Content: errorContent}
+	notFound := apidoc.Response{Description: "User does not exist", Content: errorContent}
+
+	doc.AddOperation(http.MethodGet, prefix+"/user/{id}", &apidoc.Operation{
+		OperationID: operationID("getUser", prefix),
+		Summary:     "Get a user profile by id",
+		Tags:        []string{"profile"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Parameters: []apidoc.Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &apidoc.Schema{Type: "string", Format: "uuid"}},
+		},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "User profile", Content: profile},
+			"401": unauthorized,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation(http.MethodGet, prefix+"/user/me", &apidoc.Operation{
+		OperationID: operationID("getCurrentUser", prefix),
+		Summary:     "Get the profile of the authenticated user",
+		Tags:        []string{"profile"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "User profile", Content: profile},
+			"401": unauthorized,
+		},
+	})
+	doc.AddOperation(http.MethodPatch, prefix+"/user/me", &apidoc.Operation{
+		OperationID: operationID("updateCurrentUser", prefix),
+		Summary:     "Update profile fields of the authenticated user, omitted fields are left unchanged",
+		Tags:        []string{"profile"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.UserUpdateRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Updated profile", Content: profile},
+			"400": {Description: "Invalid body", Content: errorContent},
+			"401": unauthorized,
+			"409": {Description: "Username is already taken", Content: errorContent},
+			"413": {Description: "Body too large", Content: errorContent},
+		},
+	})
+	doc.AddOperation(http.MethodDelete, prefix+"/user/me", &apidoc.Operation{
+		OperationID: operationID("deleteCurrentUser", prefix),
+		Summary:     "Delete the authenticated user, revoke its tokens and close its WebSocket connections",
+		Tags:        []string{"profile"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Responses: map[string]apidoc.Response{
+			"204": {Description: "User deleted"},
+			"401": unauthorized,
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/user/me/password", &apidoc.Operation{
+		OperationID: operationID("changePassword", prefix),
+		Summary:     "Change the password of the authenticated user and revoke its other sessions",
+		Tags:        []string{"password"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.PasswordChangeRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"204": {Description: "Password changed"},
+			"400": {Description: "Invalid body or new password rejected by policy", Content: errorContent},
+			"401": unauthorized,
+			"403": {Description: "Current password does not match", Content: errorContent},
+			"413": {Description: "Body too large", Content: errorContent},
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/user/password/reset", &apidoc.Operation{
+		OperationID: operationID("requestPasswordReset", prefix),
+		Summary:     "Send a single-use, time-limited password reset token to the user",
+		Tags:        []string{"password"},
+		Deprecated:  deprecated,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.PasswordResetRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"202": {Description: "Reset requested, returned for unknown users as well"},
+			"400": {Description: "Invalid body", Content: errorContent},
+			"413": {Description: "Body too large", Content: errorContent},
+			"503": {Description: "Reset token could not be generated or delivered"},
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/user/password/reset/confirm", &apidoc.Operation{
+		OperationID: operationID("resetPassword", prefix),
+		Summary:     "Set a new password using a reset token and revoke all sessions",
+		Tags:        []string{"password"},
+		Deprecated:  deprecated,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.PasswordResetConfirmRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"204": {Description: "Password reset"},
+			"400": {Description: "Invalid body, invalid or expired token, or new password rejected by policy", Content: errorContent},
+			"413": {Description: "Body too large", Content: errorContent},
+		},
+	})
+
+	documentEvents(doc, prefix, deprecated)
+	documentAdmin(doc, prefix, deprecated)
+}
+
+func documentEvents(doc *apidoc.OpenAPI, prefix string, deprecated bool) {
+	bearer := []map[string][]string{{"bearer": {}}}
+	errorContent := apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))
+	unauthorized := apidoc.Response{Description: "Missing or invalid session token", Content: errorContent}
+
+	doc.AddOperation(http.MethodGet, prefix+"/events", &apidoc.Operation{
+		OperationID: operationID("streamEvents", prefix),
+		Summary:     "Server-Sent Events stream of messages and presence changes, see the AsyncAPI events channel",
+		Tags:        []string{"events"},
+		Deprecated:  deprecated,
+		Security:    []map[string][]string{{"bearer": {}}, {}},
+		Parameters: []apidoc.Parameter{
+			{Name: "token", In: "query", Description: "Stream token from POST /events/token, which survives EventSource reconnects, or the one-time token from the login response, used when no bearer token is sent", Schema: &apidoc.Schema{Type: "string"}},
+			{Name: "lastEventId", In: "query", Description: "Resume after this event id, the Last-Event-ID header takes precedence", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(0)}},
+			{Name: "Last-Event-ID", In: "header", Description: "Resume after this event id", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(0)}},
+		},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Event stream", Content: map[string]apidoc.MediaType{"text/event-stream": {Schema: &apidoc.Schema{Type: "string"}}}},
+			"400": {Description: "Invalid last event id", Content: errorContent},
+			"401": unauthorized,
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/events/token", &apidoc.Operation{
+		OperationID: operationID("createStreamToken", prefix),
+		Summary:     "Issue a token for the token query parameter of /events, reusable until it expires so EventSource can reconnect",
+		Tags:        []string{"events"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Responses: map[string]apidoc.Response{
+			"201": {Description: "Stream token", Content: apidoc.JSONContent(doc.SchemaRef(responses.StreamTokenResponse{}))},
+			"401": unauthorized,
+		},
+	})
+	doc.AddOperation(http.MethodGet, prefix+"/poll", &apidoc.Operation{
+		OperationID: operationID("pollEvents", prefix),
+		Summary:     "Long-polling fallback: wait for events newer than since, or return an empty list after the poll timeout",
+		Description: "The first poll consumes the one-time login token and keeps an event queue under it, later polls with the same token " +
+			"resume that queue while the user exists and is not banned, every poll is authenticated again. Events stay queued until a poll with a since at or above their id acknowledges them. " +
+			"A queue that is not polled for twice the poll timeout is closed.",
+		Tags:       []string{"events"},
+		Deprecated: deprecated,
+		Security:   []map[string][]string{{"bearer": {}}, {}},
+		Parameters: []apidoc.Parameter{
+			{Name: "token", In: "query", Description: "One-time token from the login response, used when no bearer token is sent", Schema: &apidoc.Schema{Type: "string"}},
+			{Name: "since", In: "query", Description: "lastEventId of the previous response", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(0)}},
+		},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Events newer than since, possibly empty", Content: apidoc.JSONContent(doc.SchemaRef(responses.PollResponse{}))},
+			"400": {Description: "Invalid since", Content: errorContent},
+			"401": unauthorized,
+			"409": {Description: "Another poll with the same token is in progress", Content: errorContent},
+			"410": {Description: "The queue fell behind under the disconnect send buffer policy, or more events than the send buffer waited to be acknowledged, poll again with a bearer token or a new login", Content: errorContent},
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/messages", &apidoc.Operation{
+		OperationID: operationID("sendMessage", prefix),
+		Summary:     "Send a chat message to all users, or to a single recipient when to is set",
+		Tags:        []string{"events"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.MessageRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"202": {Description: "Message published", Content: apidoc.JSONContent(doc.SchemaRef(responses.MessageResponse{}))},
+			"400": {Description: "Invalid body", Content: errorContent},
+			"401": unauthorized,
+			"404": {Description: "Recipient does not exist", Content: errorContent},
+			"413": {Description: "Body too large", Content: errorContent},
+		},
+	})
+}
+
+func documentAdmin(doc *apidoc.OpenAPI, prefix string, deprecated bool) {
+	bearer := []map[string][]string{{"bearer": {}}}
+	errorContent := apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))
+	adminUser := apidoc.JSONContent(doc.SchemaRef(responses.AdminUserResponse{}))
+	unauthorized := apidoc.Response{Description: "Missing or invalid session token", Content: errorContent}
+	forbidden := apidoc.Response{Description: "Missing permission", Content: errorContent}
+	userID := apidoc.Parameter{Name: "id", In: "path", Required: true, Schema: &apidoc.Schema{Type: "string", Format: "uuid"}}
+
+	doc.AddOperation(http.MethodGet, prefix+"/admin/users", &apidoc.Operation{
+		OperationID: operationID("adminListUsers", prefix),
+		Summary:     "List users ordered by username, requires users:read",
+		Tags:        []string{"admin"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Parameters: []apidoc.Parameter{
+			{Name: "offset", In: "query", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(0)}},
+			{Name: "limit", In: "query", Schema: &apidoc.Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(100)}},
+		},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Page of users", Content: apidoc.JSONContent(doc.SchemaRef(responses.AdminUserListResponse{}))},
+			"400": {Description: "Invalid pagination parameters", Content: errorContent},
+			"401": unauthorized,
+			"403": forbidden,
+		},
+	})
+	doc.AddOperation(http.MethodPut, prefix+"/admin/users/{id}/ban", &apidoc.Operation{
+		OperationID: operationID("adminBanUser", prefix),
+		Summary:     "Ban a user, revoking its tokens and closing its WebSocket connections, requires users:ban",
+		Tags:        []string{"admin"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Parameters:  []apidoc.Parameter{userID},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Banned user", Content: adminUser},
+			"401": unauthorized,
+			"403": forbidden,
+			"404": {Description: "User does not exist", Content: errorContent},
+		},
+	})
+	doc.AddOperation(http.MethodDelete, prefix+"/admin/users/{id}/ban", &apidoc.Operation{
+		OperationID: operationID("adminUnbanUser", prefix),
+		Summary:     "Lift a ban, requires users:ban",
+		Tags:        []string{"admin"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Parameters:  []apidoc.Parameter{userID},
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Unbanned user", Content: adminUser},
+			"401": unauthorized,
+			"403": forbidden,
+			"404": {Description: "User does not exist", Content: errorContent},
+		},
+	})
+	doc.AddOperation(http.MethodGet, prefix+"/admin/sessions", &apidoc.Operation{
+		OperationID: operationID("adminListSessions", prefix),
+		Summary:     "List users with live WebSocket connections, requires sessions:read",
+		Tags:        []string{"admin"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Active sessions", Content: apidoc.JSONContent(&apidoc.Schema{Type: "array", Items: doc.SchemaRef(responses.ActiveSessionResponse{})})},
+			"401": unauthorized,
+			"403": forbidden,
+		},
+	})
+	doc.AddOperation(http.MethodDelete, prefix+"/admin/users/{id}/connections", &apidoc.Operation{
+		OperationID: operationID("adminDisconnectUser", prefix),
+		Summary:     "Close all WebSocket connections of a user, requires sessions:disconnect",
+		Tags:        []string{"admin"},
+		Deprecated:  deprecated,
+		Security:    bearer,
+		Parameters:  []apidoc.Parameter{userID},
+		Responses: map[string]apidoc.Response{
+			"204": {Description: "Connections closed"},
+			"401": unauthorized,
+			"403": forbidden,
+			"404": {Description: "User has no live connection", Content: errorContent},
+		},
+	})
+}
+
+func documentLegacy(doc *apidoc.OpenAPI, prefix string, deprecated bool) {
+	documentShared(doc, prefix, deprecated)
+
+	doc.AddOperation(http.MethodGet, prefix+"/user/active/list", &apidoc.Operation{
+		OperationID: operationID("listActiveUsers", prefix),
+		Summary:     "List names of users with an open WebSocket connection",
+		Tags:        []string{"user"},
+		Deprecated:  deprecated,
+		Responses: map[string]apidoc.Response{
+			"200": {Description: "Active user names", Content: apidoc.JSONContent(&apidoc.Schema{Type: "array", Items: &apidoc.Schema{Type: "string"}})},
+		},
+	})
+}
+
+func documentShared(doc *apidoc.OpenAPI, prefix string, deprecated bool) {
+	invalidBody := apidoc.Response{Description: "Invalid body", Content: apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))}
+	bodyTooLarge := apidoc.Response{Description: "Body too large", Content: apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))}
+
+	doc.AddOperation(http.MethodPost, prefix+"/user", &apidoc.Operation{
+		OperationID: operationID("createUser", prefix),
+		Summary:     "Register a new user",
+		Tags:        []string{"user"},
+		Deprecated:  deprecated,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.UserRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"201": {Description: "User created", Content: apidoc.JSONContent(doc.SchemaRef(responses.UserResponse{}))},
+			"400": invalidBody,
+			"409": {Description: "Username is already taken", Content: apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))},
+			"413": bodyTooLarge,
+		},
+	})
+	doc.AddOperation(http.MethodPost, prefix+"/user/login", &apidoc.Operation{
+		OperationID: operationID("loginUser", prefix),
+		Summary:     "Log in and receive a session token and a one-time WebSocket token",
+		Tags:        []string{"user"},
+		Deprecated:  deprecated,
+		RequestBody: &apidoc.RequestBody{Required: true, Content: apidoc.JSONContent(doc.SchemaRef(requests.UserLoginRequest{}))},
+		Responses: map[string]apidoc.Response{
+			"201": {
+				Description: "Logged in",
+				Headers: map[string]apidoc.Header{
+					"X-Rate-Limit":    {Schema: &apidoc.Schema{Type: "integer"}},
+					"X-Expires-After": {Schema: &apidoc.Schema{Type: "string"}},
+				},
+				Content: apidoc.JSONContent(doc.SchemaRef(responses.UserLoginResponse{})),
+			},
+			"400": {Description: "Invalid body or credentials", Content: apidoc.JSONContent(doc.SchemaRef(responses.ErrorResponse{}))},
+			"413": bodyTooLarge,
+			"503": {Description: "Token could not be generated"},
+		},
+	})
+	doc.AddOperation(http.MethodGet, prefix+"/ws", &apidoc.Operation{
+		OperationID: operationID("connectWebsocket", prefix),
+		Summary:     "Upgrade to a WebSocket connection, see /asyncapi.json for events",
+		Tags:        []string{"websocket"},
+		Deprecated:  deprecated,
+		Parameters: []apidoc.Parameter{
+			{Name: "token", In: "query", Description: "Deprecated, one-time token from the login response", Schema: &apidoc.Schema{Type: "string"}},
+			{Name: "session", In: "query", Description: "Deprecated, session token of a previous connection to resume", Schema: &apidoc.Schema{Type: "string"}},
+		},
+		Responses: map[string]apidoc.Response{
+			"101": {Description: "Switching protocols"},
+			"400": {Description: "Unsupported protocol version"},
+			"401": {Description: "Invalid or already used token"},
+			"403": {Description: "Origin not allowed"},
+		},
+	})
+}
+
+func operationID(name string, prefix string) string {
+	if prefix == "" {
+		return name + "Legacy"
+	}
+
+	version := strings.TrimPrefix(prefix, apiPrefix+"/")
+	return name + strings.ToUpper(version[:1]) + version[1:]
+}
+
+func intPtr(value int) *int {
+	return &value
+}
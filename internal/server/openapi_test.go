@@ -0,0 +1,94 @@
+package server_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func getDocument(t *testing.T, handler http.Handler, path string) map[string]interface{} {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	return doc
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	srv := server.New(server.WithLogger(zaptest.NewLogger(t)))
+	doc := getDocument(t, srv, "/openapi.json")
+
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	paths := doc["paths"].(map[string]interface{})
+
+	err := chi.Walk(srv.Routes(), func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if route == "" {
+			route = "/"
+		}
+
+		item, ok := paths[route].(map[string]interface{})
+		if assert.True(t, ok, "route %s is missing from the OpenAPI document", route) {
+			assert.Contains(t, item, strings.ToLower(method), "operation %s %s is missing from the OpenAPI document", method, route)
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestOpenAPI_LegacyRoutesAreDeprecated(t *testing.T) {
+	doc := getDocument(t, server.New(), "/openapi.json")
+	paths := doc["paths"].(map[string]interface{})
+
+	legacy := paths["/user/login"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Equal(t, true, legacy["deprecated"])
+
+	current := paths["/api/v1/user/login"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.NotContains(t, current, "deprecated")
+}
+
+func TestOpenAPI_ResponseSchemas(t *testing.T) {
+	doc := getDocument(t, server.New(), "/openapi.json")
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	assert.Contains(t, schemas, "UserResponse")
+	assert.Contains(t, schemas, "UserLoginResponse")
+}
+
+func TestAsyncAPI_DocumentsWebsocketEvents(t *testing.T) {
+	doc := getDocument(t, server.New(), "/asyncapi.json")
+
+	assert.Equal(t, "3.0.0", doc["asyncapi"])
+	messages := doc["components"].(map[string]interface{})["messages"].(map[string]interface{})
+	assert.Contains(t, messages, "echo")
+	assert.Contains(t, messages, "message")
+	assert.Contains(t, messages, "presence")
+
+	operations := doc["operations"].(map[string]interface{})
+	assert.Contains(t, operations, "sendEcho")
+	assert.Contains(t, operations, "receiveEcho")
+	assert.Contains(t, operations, "receiveMessageRequest")
+	assert.Contains(t, operations, "sendMessage")
+	assert.Contains(t, operations, "sendSseMessage")
+	assert.Contains(t, operations, "sendWelcome")
+	assert.Contains(t, operations, "sendError")
+	assert.Contains(t, operations, "sendWarning")
+	assert.Contains(t, operations, "receiveAuth")
+	assert.Contains(t, operations, "receiveAck")
+
+	channels := doc["channels"].(map[string]interface{})
+	assert.Contains(t, channels, "events")
+}
@@ -0,0 +1,107 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"httpserver/internal/server"
+	"httpserver/internal/storage"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	tokens []string
+}
+
+func (n *recordingNotifier) NotifyPasswordReset(ctx context.Context, user *storage.User, token string, expiresAt time.Time) error {
+	n.tokens = append(n.tokens, token)
+	return nil
+}
+
+func TestServer_ChangePasswordRevokesOtherSessions(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+	wsToken, otherToken := loginSession(t, apiUrl, "JohnDoe", "password123")
+	connectedToken, _ := loginSession(t, apiUrl, "JohnDoe", "password123")
+	conn, _ := dialWs(t, "ws"+apiUrl[4:]+"/ws?token="+connectedToken)
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/user/me/password", token, strings.NewReader(`{"currentPassword":"wrongpassword","newPassword":"newpassword123"}`))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/user/me/password", token, strings.NewReader(`{"currentPassword":"password123","newPassword":"short"}`))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "newPassword", body["fields"].([]interface{})[0].(map[string]interface{})["field"])
+
+	resp = authorized(t, http.MethodPost, apiUrl+"/user/me/password", token, strings.NewReader(`{"currentPassword":"password123","newPassword":"newpassword123"}`))
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", token, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", otherToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assertClosed(t, conn)
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = postJSON(t, apiUrl+"/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	loginSession(t, apiUrl, "JohnDoe", "newpassword123")
+}
+
+func TestServer_PasswordReset(t *testing.T) {
+	resetNotifier := &recordingNotifier{}
+	testServer := newTestServer(t, server.WithNotifier(resetNotifier))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp := postJSON(t, apiUrl+"/user/password/reset", `{"userName":"Unknown"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Empty(t, resetNotifier.tokens)
+
+	resp = postJSON(t, apiUrl+"/user/password/reset", `{"userName":"JohnDoe"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	postJSON(t, apiUrl+"/user/password/reset", `{"userName":"JohnDoe"}`)
+	require.Len(t, resetNotifier.tokens, 2)
+
+	// Only the latest link works, and a password the policy rejects does not use it up.
+	resp = postJSON(t, apiUrl+"/user/password/reset/confirm", `{"token":"`+resetNotifier.tokens[0]+`","newPassword":"newpassword123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, apiUrl+"/user/password/reset/confirm", `{"token":"`+resetNotifier.tokens[1]+`","newPassword":"short"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, apiUrl+"/user/password/reset/confirm", `{"token":"`+resetNotifier.tokens[1]+`","newPassword":"newpassword123"}`)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = postJSON(t, apiUrl+"/user/password/reset/confirm", `{"token":"`+resetNotifier.tokens[1]+`","newPassword":"otherpassword123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	loginSession(t, apiUrl, "JohnDoe", "newpassword123")
+}
+
+func TestServer_PasswordResetTokenExpires(t *testing.T) {
+	resetNotifier := &recordingNotifier{}
+	testServer := newTestServer(t, server.WithNotifier(resetNotifier), server.WithPasswordResetTTL(time.Nanosecond))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	postJSON(t, apiUrl+"/user/password/reset", `{"userName":"JohnDoe"}`)
+	require.Len(t, resetNotifier.tokens, 1)
+
+	resp := postJSON(t, apiUrl+"/user/password/reset/confirm", `{"token":"`+resetNotifier.tokens[0]+`","newPassword":"newpassword123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
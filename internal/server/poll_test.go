@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"httpserver/internal/server"
+	"httpserver/internal/storage/userstorage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pollPage struct {
+	Events []struct {
+		ID   uint64            `json:"id"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	} `json:"events"`
+	LastEventId uint64 `json:"lastEventId"`
+}
+
+func poll(t *testing.T, url string, token string, since uint64) (int, pollPage) {
+	resp, err := http.Get(url + "/poll?token=" + token + "&since=" + strconv.FormatUint(since, 10))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var page pollPage
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	}
+
+	return resp.StatusCode, page
+}
+
+func TestServer_PollRequiresToken(t *testing.T) {
+	testServer := newTestServer(t)
+
+	status, _ := poll(t, testServer.URL+"/api/v1", "invalid", 0)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestServer_PollKeepsQueueForLoginToken(t *testing.T) {
+	testServer := newTestServer(t, server.WithPollTimeout(50*time.Millisecond))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token, session := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	status, page := poll(t, apiUrl, token, 0)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, "presence", page.Events[0].Type)
+	assert.Equal(t, "online", page.Events[0].Data["status"])
+
+	status, page = poll(t, apiUrl, token, page.LastEventId)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, page.Events)
+
+	resp := authorized(t, http.MethodPost, apiUrl+"/messages", session, strings.NewReader(`{"text":"hello"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	status, page = poll(t, apiUrl, token, page.LastEventId)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, "hello", page.Events[0].Data["text"])
+}
+
+func TestServer_PollCountsAsActiveConnection(t *testing.T) {
+	testServer := newTestServer(t, server.WithPollTimeout(20*time.Millisecond))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token, session := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	status, _ := poll(t, apiUrl, token, 0)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, getActiveUsers(t, apiUrl+"/user/active/list").Total)
+
+	resp := authorized(t, http.MethodDelete, apiUrl+"/user/me", session, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	status, _ = poll(t, apiUrl, token, 0)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, 0, getActiveUsers(t, apiUrl+"/user/active/list").Total)
+}
+
+func TestServer_PollQueueExpires(t *testing.T) {
+	testServer := newTestServer(t, server.WithPollTimeout(10*time.Millisecond))
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token, _ := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	status, _ := poll(t, apiUrl, token, 0)
+	require.Equal(t, http.StatusOK, status)
+
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, apiUrl+"/user/active/list").Total == 0
+	}, time.Second, 10*time.Millisecond)
+	status, _ = poll(t, apiUrl, token, 0)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestServer_PollChecksTheUserOnEveryPoll(t *testing.T) {
+	users := userstorage.NewUserStorage()
+	testServer := newTestServer(t, server.WithUserStorage(users), server.WithPollTimeout(20*time.Millisecond))
+	apiUrl := testServer.URL + "/api/v1"
+
+	resp := postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	token, _ := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	status, _ := poll(t, apiUrl, token, 0)
+	require.Equal(t, http.StatusOK, status)
+
+	// The ban is written to the storage directly, so no disconnect closes the queue.
+	user, err := users.GetByID(context.Background(), created["id"])
+	require.NoError(t, err)
+	user.Banned = true
+	require.NoError(t, users.Update(context.Background(), user))
+
+	status, _ = poll(t, apiUrl, token, 0)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, 0, getActiveUsers(t, apiUrl+"/user/active/list").Total, "the queue is closed")
+}
@@ -0,0 +1,214 @@
+package server_test
+
+import (
+	"encoding/json"
+	"httpserver/internal/server"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginSession(t *testing.T, baseUrl string, userName string, password string) (string, string) {
+	resp := postJSON(t, baseUrl+"/user/login", `{"userName":"`+userName+`","password":"`+password+`"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return body["wsToken"], body["token"]
+}
+
+func authorized(t *testing.T, method string, url string, token string, body io.Reader) *http.Response {
+	req, err := http.NewRequest(method, url, body)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestServer_ProfileRequiresSession(t *testing.T) {
+	testServer := newTestServer(t)
+
+	resp, err := http.Get(testServer.URL + "/api/v1/user/me")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_ProfileReadAndUpdate(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp := authorized(t, http.MethodPatch, apiUrl+"/user/me", token, strings.NewReader(`{"displayName":"John","bio":"Hello"}`))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var profile map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&profile))
+	assert.Equal(t, "JohnDoe", profile["userName"])
+	assert.Equal(t, "John", profile["displayName"])
+	assert.Equal(t, "Hello", profile["bio"])
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/"+profile["id"], token, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authorized(t, http.MethodPatch, apiUrl+"/user/me", token, strings.NewReader(`{"avatarUrl":"not a url"}`))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_DeleteMeRevokesSessionsAndDisconnects(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	wsToken, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(apiUrl + "/user/active/list")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		var list struct {
+			Total int `json:"total"`
+		}
+		json.NewDecoder(resp.Body).Decode(&list)
+		return list.Total == 1
+	}, time.Second, 10*time.Millisecond)
+
+	resp := authorized(t, http.MethodDelete, apiUrl+"/user/me", token, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	assertClosed(t, conn)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/me", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = postJSON(t, apiUrl+"/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_UserNameIsUniqueAlias(t *testing.T) {
+	testServer := newTestServer(t)
+	apiUrl := testServer.URL + "/api/v1"
+
+	resp := postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	resp = postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password456"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	postJSON(t, apiUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+
+	_, token := loginSession(t, apiUrl, "JohnDoe", "password123")
+
+	resp = authorized(t, http.MethodPatch, apiUrl+"/user/me", token, strings.NewReader(`{"userName":"JaneDoe"}`))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = authorized(t, http.MethodPatch, apiUrl+"/user/me", token, strings.NewReader(`{"userName":"a b"}`))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authorized(t, http.MethodPatch, apiUrl+"/user/me", token, strings.NewReader(`{"userName":"Johnny"}`))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/"+created["id"], token, nil)
+	var profile map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&profile))
+	assert.Equal(t, "Johnny", profile["userName"])
+
+	resp = postJSON(t, apiUrl+"/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	loginSession(t, apiUrl, "Johnny", "password123")
+}
+
+func TestServer_ConcurrentRequestsOfOneUser(t *testing.T) {
+	// newTestServer logs through zaptest, whose lock serialises the handlers,
+	// so this server keeps the default logger to let the requests overlap.
+	srv := server.New(server.WithAdmin("root", "rootpassword"))
+	testServer := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		testServer.Close()
+		srv.Close()
+	})
+	apiUrl := testServer.URL + "/api/v1"
+
+	resp := postJSON(t, apiUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	wsToken, userToken := loginSession(t, apiUrl, "JohnDoe", "password123")
+	_, adminToken := loginSession(t, apiUrl, "root", "rootpassword")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+apiUrl[4:]+"/ws?token="+wsToken, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	requests := []struct {
+		method string
+		path   string
+		token  string
+		body   string
+	}{
+		{http.MethodPatch, "/user/me", userToken, `{"statusText":"busy"}`},
+		{http.MethodPatch, "/user/me", userToken, `{"bio":"Hello"}`},
+		{http.MethodGet, "/user/me", userToken, ""},
+		{http.MethodGet, "/user/active/list", userToken, ""},
+		{http.MethodGet, "/user/" + created["id"], adminToken, ""},
+		{http.MethodPut, "/admin/users/" + created["id"] + "/ban", adminToken, ""},
+		{http.MethodDelete, "/admin/users/" + created["id"] + "/ban", adminToken, ""},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		for _, request := range requests {
+			wg.Add(1)
+			go func(method, path, token, body string) {
+				defer wg.Done()
+				req, err := http.NewRequest(method, apiUrl+path, strings.NewReader(body))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}(request.method, request.path, request.token, request.body)
+		}
+	}
+	wg.Wait()
+
+	resp = authorized(t, http.MethodGet, apiUrl+"/user/"+created["id"], adminToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var profile map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&profile))
+	assert.Equal(t, "JohnDoe", profile["userName"])
+}
@@ -0,0 +1,213 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"httpserver/internal/pubsub"
+	"httpserver/internal/server"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// readPresence returns the next presence of the user, skipping the other events.
+func readPresence(t *testing.T, conn *websocket.Conn, userName string) string {
+	t.Helper()
+
+	for {
+		var presence map[string]string
+		require.NoError(t, json.Unmarshal(readUntil(t, conn, "presence").Payload, &presence))
+		if presence["userName"] == userName {
+			return presence["status"]
+		}
+	}
+}
+
+// newRelayedInstance starts an instance relaying its events through redis, with storages of its own like the
+// instances started by main.
+func newRelayedInstance(t *testing.T, redis *miniredis.Miniredis, options ...server.Option) string {
+	relay, err := pubsub.New(context.Background(), "redis://"+redis.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { relay.Close() })
+
+	return newTestServer(t, append([]server.Option{server.WithPubSub(relay)}, options...)...).URL + "/api/v1"
+}
+
+func TestServer_UsersOnDifferentInstancesChat(t *testing.T) {
+	redis := miniredis.RunT(t)
+	firstUrl, secondUrl := newRelayedInstance(t, redis), newRelayedInstance(t, redis)
+
+	postJSON(t, firstUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	postJSON(t, secondUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+	johnToken, _ := loginSession(t, firstUrl, "JohnDoe", "password123")
+	janeToken, janeSession := loginSession(t, secondUrl, "JaneDoe", "password123")
+
+	john, _ := dialWs(t, "ws"+firstUrl[4:]+"/ws?token="+johnToken)
+	jane, welcome := dialWs(t, "ws"+secondUrl[4:]+"/ws?token="+janeToken)
+	janeID := welcome["userId"].(string)
+
+	assert.Equal(t, "online", readPresence(t, john, "JaneDoe"))
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, firstUrl+"/user/active/list").Total == 2
+	}, time.Second, 10*time.Millisecond)
+
+	resp := authorized(t, http.MethodPost, secondUrl+"/messages", janeSession, strings.NewReader(`{"text":"hello"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	johnCopy, janeCopy := readUntil(t, john, "message"), readUntil(t, jane, "message")
+	assert.Equal(t, "hello", messageText(t, johnCopy))
+	assert.Equal(t, janeCopy.ID, johnCopy.ID, "Both instances should give the message the same id")
+
+	require.NoError(t, john.WriteJSON(map[string]interface{}{
+		"type":    "message",
+		"payload": map[string]string{"text": "hi Jane", "to": janeID},
+	}))
+	assert.Equal(t, "hi Jane", messageText(t, readUntil(t, jane, "message")), "A user registered on another instance can receive direct messages")
+
+	jane.Close()
+	assert.Equal(t, "offline", readPresence(t, john, "JaneDoe"))
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, firstUrl+"/user/active/list").Total == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_ActiveUsersFollowInstances(t *testing.T) {
+	redis := miniredis.RunT(t)
+	newInstance := func() (*server.Server, string) {
+		relay, err := pubsub.New(context.Background(), "redis://"+redis.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() { relay.Close() })
+
+		srv := server.New(server.WithLogger(zaptest.NewLogger(t)), server.WithPubSub(relay))
+		testServer := httptest.NewServer(srv)
+		t.Cleanup(testServer.Close)
+
+		return srv, testServer.URL + "/api/v1"
+	}
+	first, firstUrl := newInstance()
+
+	postJSON(t, firstUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	johnToken, _ := loginSession(t, firstUrl, "JohnDoe", "password123")
+	dialWs(t, "ws"+firstUrl[4:]+"/ws?token="+johnToken)
+
+	second, secondUrl := newInstance()
+	defer second.Close()
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, secondUrl+"/user/active/list").Total == 1
+	}, time.Second, 10*time.Millisecond, "An instance started later should ask for the users already connected")
+	assert.Equal(t, "JohnDoe", getActiveUsers(t, secondUrl+"/user/active/list").Users[0].UserName,
+		"A user of another instance should be named after their presence")
+
+	first.Close()
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, secondUrl+"/user/active/list").Total == 0
+	}, time.Second, 10*time.Millisecond, "The users of an instance that stopped should not stay active")
+}
+
+func TestServer_AdminDisconnectsUserOfOtherInstance(t *testing.T) {
+	redis := miniredis.RunT(t)
+	firstUrl := newRelayedInstance(t, redis, server.WithAdmin("root", "rootpassword"))
+	secondUrl := newRelayedInstance(t, redis)
+
+	resp := postJSON(t, secondUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	johnToken, _ := loginSession(t, secondUrl, "JohnDoe", "password123")
+	_, adminToken := loginSession(t, firstUrl, "root", "rootpassword")
+	john, _ := dialWs(t, "ws"+secondUrl[4:]+"/ws?token="+johnToken)
+
+	assert.Eventually(t, func() bool {
+		resp := authorized(t, http.MethodDelete, firstUrl+"/admin/users/"+created["id"]+"/connections", adminToken, nil)
+		return resp.StatusCode == http.StatusNoContent
+	}, time.Second, 10*time.Millisecond)
+
+	assertClosed(t, john)
+	assert.Eventually(t, func() bool {
+		return getActiveUsers(t, firstUrl+"/user/active/list").Total == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_UserConnectedToTwoInstancesStaysOnline(t *testing.T) {
+	redis := miniredis.RunT(t)
+	// The storages are shared, as with a common backend, so the same user can connect to both instances.
+	shared := []server.Option{
+		server.WithUserStorage(userstorage.NewUserStorage()),
+		server.WithTokenStorage(tokenstorage.NewTokenStorage()),
+		server.WithSessionStorage(sessionstorage.NewSessionStorage()),
+		server.WithAdmin("root", "rootpassword"),
+	}
+	firstUrl, secondUrl := newRelayedInstance(t, redis, shared...), newRelayedInstance(t, redis, shared...)
+
+	resp := postJSON(t, firstUrl+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	postJSON(t, firstUrl+"/user", `{"userName":"JaneDoe","password":"password123"}`)
+	firstToken, _ := loginSession(t, firstUrl, "JohnDoe", "password123")
+	secondToken, _ := loginSession(t, secondUrl, "JohnDoe", "password123")
+	janeToken, janeSession := loginSession(t, firstUrl, "JaneDoe", "password123")
+	_, adminToken := loginSession(t, firstUrl, "root", "rootpassword")
+
+	jane, _ := dialWs(t, "ws"+firstUrl[4:]+"/ws?token="+janeToken)
+	first, _ := dialWs(t, "ws"+firstUrl[4:]+"/ws?token="+firstToken)
+	assert.Equal(t, "online", readPresence(t, jane, "JohnDoe"))
+	second, _ := dialWs(t, "ws"+secondUrl[4:]+"/ws?token="+secondToken)
+	defer second.Close()
+	assert.Eventually(t, func() bool {
+		var sessions []map[string]interface{}
+		json.NewDecoder(authorized(t, http.MethodGet, firstUrl+"/admin/sessions", adminToken, nil).Body).Decode(&sessions)
+		return len(sessions) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	first.Close()
+	assert.Eventually(t, func() bool {
+		var sessions []map[string]interface{}
+		json.NewDecoder(authorized(t, http.MethodGet, firstUrl+"/admin/sessions", adminToken, nil).Body).Decode(&sessions)
+		for _, session := range sessions {
+			if session["id"] == created["id"] {
+				return session["connections"] == float64(0)
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond, "John should stay active while connected to the second instance")
+
+	resp = authorized(t, http.MethodPost, firstUrl+"/messages", janeSession, strings.NewReader(`{"text":"hello"}`))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	for {
+		e := readUntilAny(t, jane, "presence", "message")
+		if e.Type == "message" {
+			break
+		}
+		var presence map[string]string
+		require.NoError(t, json.Unmarshal(e.Payload, &presence))
+		assert.NotEqual(t, "offline", presence["status"], "John is still connected to the second instance")
+	}
+
+	second.Close()
+	assert.Equal(t, "offline", readPresence(t, jane, "JohnDoe"))
+}
+
+// readUntilAny returns the next envelope of one of the given types, skipping the others.
+func readUntilAny(t *testing.T, conn *websocket.Conn, eventTypes ...string) envelope {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for {
+		var e envelope
+		require.NoError(t, conn.ReadJSON(&e))
+		for _, eventType := range eventTypes {
+			if e.Type == eventType {
+				return e
+			}
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"httpserver/internal/apidoc"
+	"httpserver/internal/auth"
+	"httpserver/internal/controller"
+	"httpserver/internal/cors"
+	"httpserver/internal/hub"
+	"httpserver/internal/logging"
+	"httpserver/internal/metrics"
+	"httpserver/internal/notifier"
+	"httpserver/internal/policy"
+	"httpserver/internal/pubsub"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/resettokenstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"httpserver/internal/tracing"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+type Server struct {
+	router             chi.Router
+	logger             *zap.Logger
+	userStorage        userstorage.UserStorageInterface
+	tokenStorage       tokenstorage.TokenStorageInterface
+	activeUsersStorage activeuserstorage.ActiveUsersStorageInterface
+	sessionStorage     sessionstorage.SessionStorageInterface
+	resetTokenStorage  resettokenstorage.ResetTokenStorageInterface
+	streamTokenStorage sessionstorage.SessionStorageInterface
+	hub                *hub.Hub
+	policy             *policy.Policy
+	notifier           notifier.Notifier
+	passwordResetTTL   time.Duration
+	pollTimeout        time.Duration
+	wsTimeouts         controller.WsTimeouts
+	wsLimits           controller.WsLimits
+	wsCompression      controller.WsCompression
+	allowedOrigins     cors.Origins
+	sendBuffer         int
+	overflowPolicy     hub.OverflowPolicy
+	pubsub             pubsub.PubSub
+	presenceInterval   time.Duration
+	clock              clock.Clock
+	controller         *controller.Controller
+	versions           []Version
+	admins             []admin
+
+	legacyRoutesDeprecation time.Time
+	legacyRoutesSunset      time.Time
+
+	// ready is closed once the controller is set, the hub may receive events of the other instances before.
+	ready chan struct{}
+}
+
+type Option func(*Server)
+
+type admin struct {
+	userName string
+	password string
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+func WithUserStorage(userStorage userstorage.UserStorageInterface) Option {
+	return func(s *Server) {
+		s.userStorage = userStorage
+	}
+}
+
+func WithTokenStorage(tokenStorage tokenstorage.TokenStorageInterface) Option {
+	return func(s *Server) {
+		s.tokenStorage = tokenStorage
+	}
+}
+
+func WithActiveUsersStorage(activeUsersStorage activeuserstorage.ActiveUsersStorageInterface) Option {
+	return func(s *Server) {
+		s.activeUsersStorage = activeUsersStorage
+	}
+}
+
+func WithSessionStorage(sessionStorage sessionstorage.SessionStorageInterface) Option {
+	return func(s *Server) {
+		s.sessionStorage = sessionStorage
+	}
+}
+
+func WithResetTokenStorage(resetTokenStorage resettokenstorage.ResetTokenStorageInterface) Option {
+	return func(s *Server) {
+		s.resetTokenStorage = resetTokenStorage
+	}
+}
+
+func WithStreamTokenStorage(streamTokenStorage sessionstorage.SessionStorageInterface) Option {
+	return func(s *Server) {
+		s.streamTokenStorage = streamTokenStorage
+	}
+}
+
+func WithHub(h *hub.Hub) Option {
+	return func(s *Server) {
+		s.hub = h
+	}
+}
+
+func WithPolicy(p *policy.Policy) Option {
+	return func(s *Server) {
+		s.policy = p
+	}
+}
+
+func WithNotifier(n notifier.Notifier) Option {
+	return func(s *Server) {
+		s.notifier = n
+	}
+}
+
+func WithPasswordResetTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.passwordResetTTL = ttl
+	}
+}
+
+// WithPollTimeout sets how long GET /poll waits for events before returning an empty response.
+func WithPollTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.pollTimeout = timeout
+	}
+}
+
+// WithWsTimeouts configures the WebSocket heartbeat, read deadline and idle timeout, zero fields keep the defaults.
+func WithWsTimeouts(timeouts controller.WsTimeouts) Option {
+	return func(s *Server) {
+		s.wsTimeouts = timeouts
+	}
+}
+
+// WithWsLimits bounds the size and rate of the envelopes a WebSocket client may send, zero fields keep the defaults.
+func WithWsLimits(limits controller.WsLimits) Option {
+	return func(s *Server) {
+		s.wsLimits = limits
+	}
+}
+
+// WithWsCompression enables permessage-deflate for WebSocket clients that offer it.
+func WithWsCompression(compression controller.WsCompression) Option {
+	return func(s *Server) {
+		s.wsCompression = compression
+	}
+}
+
+// WithAllowedOrigins lets browser apps served from other origins call the API and open WebSocket connections.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(s *Server) {
+		s.allowedOrigins = append(s.allowedOrigins, origins...)
+	}
+}
+
+// WithSendBuffer bounds the events waiting for each WebSocket, SSE and long-polling client and sets what happens
+// when a slow client fills its buffer. It has no effect on a hub passed with WithHub.
+func WithSendBuffer(size int, policy hub.OverflowPolicy) Option {
+	return func(s *Server) {
+		s.sendBuffer = size
+		s.overflowPolicy = policy
+	}
+}
+
+// WithPubSub relays the events of the hub through ps, so users connected to different instances can chat. Bans,
+// deletions and forced disconnects close the connections of the user on every instance, but the users, tokens and
+// sessions are kept by each instance, so the credentials are only revoked on the instance that handled the request.
+// The users connected to another instance are listed as active and receive direct messages through their presence.
+// It has no effect on a hub passed with WithHub.
+func WithPubSub(ps pubsub.PubSub) Option {
+	return func(s *Server) {
+		s.pubsub = ps
+	}
+}
+
+// WithPresenceInterval sets how often the hub announces its users to the other instances, the users of an instance
+// that misses three announcements are shown offline. It has no effect on a hub passed with WithHub.
+func WithPresenceInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.presenceInterval = interval
+	}
+}
+
+// WithClock sets the clock of the WebSocket heartbeat and limits, and of the hub: the expiry of resumable sessions, the
+// poll timers and the presence heartbeat. The hub passed with WithHub keeps its own clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// WithAdmin creates the user on startup, or grants the admin role to an existing user with that name.
+func WithAdmin(userName string, password string) Option {
+	return func(s *Server) {
+		s.admins = append(s.admins, admin{userName: userName, password: password})
+	}
+}
+
+func New(options ...Option) *Server {
+	s := &Server{
+		router:             chi.NewRouter(),
+		logger:             zap.NewNop(),
+		userStorage:        userstorage.NewUserStorage(),
+		tokenStorage:       tokenstorage.NewTokenStorage(),
+		sessionStorage:     sessionstorage.NewSessionStorage(),
+		resetTokenStorage:  resettokenstorage.NewResetTokenStorage(),
+		streamTokenStorage: sessionstorage.NewSessionStorage(),
+		policy:             policy.Default(),
+		notifier:           notifier.LogNotifier{},
+		versions:           []Version{{Name: "v1", Routes: v1Routes, Document: documentV1}},
+		clock:              clock.New(),
+		ready:              make(chan struct{}),
+
+		legacyRoutesDeprecation: defaultLegacyRoutesDeprecation,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+	if s.hub == nil {
+		s.hub = hub.New(
+			hub.WithSendBuffer(s.sendBuffer, s.overflowPolicy),
+			hub.WithDropHandler(s.droppedEvent),
+			hub.WithPubSub(s.pubsub),
+			hub.WithPresenceInterval(s.presenceInterval),
+			hub.WithRelayErrorHandler(s.relayFailed),
+			hub.WithRemoteHandler(s.remoteEvent),
+			hub.WithClock(s.clock),
+		)
+	}
+	if s.activeUsersStorage == nil {
+		s.activeUsersStorage = activeuserstorage.NewActiveUsersStorage(activeUsers{users: s.userStorage, hub: s.hub})
+	}
+
+	s.seedAdmins()
+	s.controller = &controller.Controller{
+		UserStorage:        metrics.NewUserStorage(tracing.NewUserStorage(s.userStorage)),
+		TokenStorage:       metrics.NewTokenStorage(tracing.NewTokenStorage(s.tokenStorage)),
+		ActiveUsersStorage: metrics.NewActiveUsersStorage(tracing.NewActiveUsersStorage(s.activeUsersStorage)),
+		SessionStorage:     metrics.NewSessionStorage(tracing.NewSessionStorage(s.sessionStorage)),
+		ResetTokenStorage:  metrics.NewResetTokenStorage(tracing.NewResetTokenStorage(s.resetTokenStorage)),
+		StreamTokenStorage: metrics.NewStreamTokenStorage(tracing.NewStreamTokenStorage(s.streamTokenStorage)),
+		Hub:                s.hub,
+		Policy:             s.policy,
+		Notifier:           s.notifier,
+		PasswordResetTTL:   s.passwordResetTTL,
+		PollTimeout:        s.pollTimeout,
+		WsTimeouts:         s.wsTimeouts,
+		WsLimits:           s.wsLimits,
+		WsCompression:      s.wsCompression,
+		AllowedOrigins:     s.allowedOrigins,
+		Clock:              s.clock,
+	}
+	close(s.ready)
+	s.routes()
+
+	return s
+}
+
+func (s *Server) droppedEvent(userID string, event hub.Event, policy hub.OverflowPolicy) {
+	metrics.HubDroppedEventsTotal.WithLabelValues(string(policy)).Inc()
+	s.logger.Warn("event dropped for slow subscriber",
+		zap.String("user", userID),
+		zap.Uint64("event", event.ID),
+		zap.String("policy", string(policy)),
+	)
+}
+
+func (s *Server) relayFailed(event hub.Event, err error) {
+	metrics.HubRelayErrorsTotal.Inc()
+	if event.Type == "" {
+		s.logger.Error("relay with the other instances failed", zap.Error(err))
+		return
+	}
+	s.logger.Error("event could not be relayed", zap.Uint64("event", event.ID), zap.Error(err))
+}
+
+func (s *Server) remoteEvent(event hub.Event) {
+	<-s.ready
+	s.controller.RemoteEvent(logging.NewContext(context.Background(), s.logger.Sugar()), event)
+}
+
+// activeUsers looks the active users up in the storage of this instance, and the users registered on another
+// instance through the presence they announced there.
+type activeUsers struct {
+	users userstorage.UserStorageInterface
+	hub   *hub.Hub
+}
+
+func (a activeUsers) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	user, err := a.users.GetByID(ctx, id)
+	if err != nil {
+		if presence, ok := a.hub.Presence(id); ok {
+			return &storage.User{Uuid: id, UserName: presence.UserName}, nil
+		}
+	}
+
+	return user, err
+}
+
+func (s *Server) seedAdmins() {
+	ctx := context.Background()
+	for _, a := range s.admins {
+		if _, err := s.userStorage.Add(ctx, a.userName, a.password); err != nil && !errors.Is(err, userstorage.ErrUserNameTaken) {
+			s.logger.Error("admin could not be created", zap.String("user", a.userName), zap.Error(err))
+			continue
+		}
+
+		user, err := s.userStorage.Get(ctx, a.userName)
+		if err != nil {
+			s.logger.Error("admin could not be loaded", zap.String("user", a.userName), zap.Error(err))
+			continue
+		}
+		if auth.HasRole(user, auth.RoleAdmin) {
+			continue
+		}
+
+		updated := *user
+		updated.Roles = append(append([]string{}, user.Roles...), auth.RoleAdmin)
+		if err := s.userStorage.Update(ctx, &updated); err != nil {
+			s.logger.Error("admin role could not be granted", zap.String("user", a.userName), zap.Error(err))
+		}
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// Close stops relaying events to the other instances, it is called once the HTTP server stopped serving requests.
+func (s *Server) Close() {
+	s.hub.Close()
+}
+
+func (s *Server) Routes() chi.Routes {
+	return s.router
+}
+
+func (s *Server) routes() {
+	s.router.Use(middleware.RequestID)
+	s.router.Use(tracing.Middleware)
+	s.router.Use(logging.Middleware(s.logger))
+	s.router.Use(middleware.Recoverer)
+	s.router.Use(metrics.Middleware)
+	s.router.Use(cors.Middleware(s.allowedOrigins))
+
+	s.router.Method(http.MethodGet, "/metrics", metrics.Handler())
+	s.router.Method(http.MethodGet, "/openapi.json", apidoc.Handler(s.openAPIDocument()))
+	s.router.Method(http.MethodGet, "/asyncapi.json", apidoc.Handler(s.asyncAPIDocument()))
+	s.mountVersions()
+}
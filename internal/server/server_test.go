@@ -0,0 +1,156 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"httpserver/internal/policy"
+	"httpserver/internal/server"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/userstorage"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestServer(t *testing.T, options ...server.Option) *httptest.Server {
+	options = append([]server.Option{server.WithLogger(zaptest.NewLogger(t))}, options...)
+	srv := server.New(options...)
+	testServer := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		testServer.Close()
+		srv.Close()
+	})
+
+	return testServer
+}
+
+func postJSON(t *testing.T, url string, body string) *http.Response {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func login(t *testing.T, baseUrl string, userName string, password string) string {
+	resp := postJSON(t, baseUrl+"/user/login", `{"userName":"`+userName+`","password":"`+password+`"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return body["wsToken"]
+}
+
+// assertClosed drains events until the server closes the connection, failing on a read timeout.
+func assertClosed(t *testing.T, conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		var netErr net.Error
+		assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "connection should be closed by the server")
+		return
+	}
+}
+
+func TestServer_RegisterAndLogin(t *testing.T) {
+	userStorage := userstorage.NewUserStorage()
+	testServer := newTestServer(t, server.WithUserStorage(userStorage))
+
+	resp := postJSON(t, testServer.URL+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, "JohnDoe", created["userName"])
+
+	user, err := userStorage.Get(context.Background(), "JohnDoe")
+	assert.NoError(t, err)
+	assert.Equal(t, created["id"], user.Uuid)
+
+	assert.NotEmpty(t, login(t, testServer.URL, "JohnDoe", "password123"))
+}
+
+func TestServer_LoginFollowsConfiguredPolicy(t *testing.T) {
+	userPolicy := policy.Default()
+	userPolicy.UserNameMinLength = 3
+	userPolicy.PasswordMinLength = 6
+	testServer := newTestServer(t, server.WithPolicy(userPolicy))
+
+	resp := postJSON(t, testServer.URL+"/api/v1/user", `{"userName":"Jon","password":"secret"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	assert.NotEmpty(t, login(t, testServer.URL+"/api/v1", "Jon", "secret"))
+}
+
+func TestServer_LoginUnknownUser(t *testing.T) {
+	testServer := newTestServer(t)
+
+	resp := postJSON(t, testServer.URL+"/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_WebsocketMarksUserActive(t *testing.T) {
+	userStorage := userstorage.NewUserStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(userStorage)
+	testServer := newTestServer(t, server.WithUserStorage(userStorage), server.WithActiveUsersStorage(activeUsersStorage))
+
+	postJSON(t, testServer.URL+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token := login(t, testServer.URL, "JohnDoe", "password123")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+testServer.URL[4:]+"/ws?token="+token, nil)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(testServer.URL + "/user/active/list")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		var names []string
+		json.NewDecoder(resp.Body).Decode(&names)
+		return len(names) == 1 && names[0] == "JohnDoe"
+	}, time.Second, 10*time.Millisecond)
+
+	conn.Close()
+}
+
+func TestServer_WebsocketRejectsReusedToken(t *testing.T) {
+	testServer := newTestServer(t)
+
+	postJSON(t, testServer.URL+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+	token := login(t, testServer.URL, "JohnDoe", "password123")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+testServer.URL[4:]+"/ws?token="+token, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+testServer.URL[4:]+"/ws?token="+token, nil)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	testServer := newTestServer(t)
+
+	resp, err := http.Get(testServer.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
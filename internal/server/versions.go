@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"httpserver/internal/apidoc"
+	"httpserver/internal/auth"
+	"httpserver/internal/controller"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const apiPrefix = "/api"
+
+// defaultLegacyRoutesDeprecation is the release date of /api/v1: the unversioned routes are deprecated from the moment
+// a versioned successor exists, until the sunset date set with WithLegacyRoutesSunset.
+var defaultLegacyRoutesDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+type Version struct {
+	Name        string
+	Routes      func(router chi.Router, c *controller.Controller)
+	Document    func(doc *apidoc.OpenAPI, prefix string, deprecated bool)
+	Deprecation time.Time
+	Sunset      time.Time
+}
+
+func (v Version) Prefix() string {
+	return apiPrefix + "/" + v.Name
+}
+
+func WithVersion(version Version) Option {
+	return func(s *Server) {
+		for i := range s.versions {
+			if s.versions[i].Name == version.Name {
+				s.versions[i] = version
+				return
+			}
+		}
+		s.versions = append(s.versions, version)
+	}
+}
+
+func WithVersionDeprecation(name string, deprecation time.Time, sunset time.Time) Option {
+	return func(s *Server) {
+		for i := range s.versions {
+			if s.versions[i].Name == name {
+				s.versions[i].Deprecation = deprecation
+				s.versions[i].Sunset = sunset
+			}
+		}
+	}
+}
+
+// WithLegacyRoutesDeprecation sets the date announced in the Deprecation header of the unversioned routes.
+func WithLegacyRoutesDeprecation(deprecation time.Time) Option {
+	return func(s *Server) {
+		s.legacyRoutesDeprecation = deprecation
+	}
+}
+
+func WithLegacyRoutesSunset(sunset time.Time) Option {
+	return func(s *Server) {
+		s.legacyRoutesSunset = sunset
+	}
+}
+
+func legacyRoutes(router chi.Router, c *controller.Controller) {
+	router.Post("/user", c.UserHandler)
+	router.Post("/user/login", c.UserLoginHandler)
+	router.Get("/ws", c.Ws)
+	router.Get("/user/active/list", c.UserGetActiveList)
+}
+
+func v1Routes(router chi.Router, c *controller.Controller) {
+	router.Post("/user", c.UserHandler)
+	router.Post("/user/login", c.UserLoginHandler)
+	router.Get("/ws", c.Ws)
+	router.Get("/user/active/list", c.UserListActive)
+	router.Post("/user/password/reset", c.UserRequestPasswordReset)
+	router.Post("/user/password/reset/confirm", c.UserResetPassword)
+	router.Get("/events", c.Events)
+	router.Get("/poll", c.Poll)
+
+	router.Group(func(router chi.Router) {
+		router.Use(auth.Middleware(c.SessionStorage, c.UserStorage))
+		router.Get("/user/me", c.UserGetMe)
+		router.Patch("/user/me", c.UserUpdateMe)
+		router.Delete("/user/me", c.UserDeleteMe)
+		router.Post("/user/me/password", c.UserChangePassword)
+		router.Get("/user/{id}", c.UserGetProfile)
+		router.Post("/messages", c.SendMessage)
+		router.Post("/events/token", c.CreateStreamToken)
+
+		router.With(auth.Require(auth.PermissionUsersRead)).Get("/admin/users", c.AdminListUsers)
+		router.With(auth.Require(auth.PermissionUsersBan)).Put("/admin/users/{id}/ban", c.AdminBanUser)
+		router.With(auth.Require(auth.PermissionUsersBan)).Delete("/admin/users/{id}/ban", c.AdminUnbanUser)
+		router.With(auth.Require(auth.PermissionSessionsRead)).Get("/admin/sessions", c.AdminListSessions)
+		router.With(auth.Require(auth.PermissionSessionsDisconnect)).Delete("/admin/users/{id}/connections", c.AdminDisconnectUser)
+	})
+}
+
+func (s *Server) mountVersions() {
+	s.router.Route(apiPrefix, func(api chi.Router) {
+		for i, version := range s.versions {
+			version := version
+			successor := ""
+			if i+1 < len(s.versions) {
+				successor = s.versions[i+1].Prefix()
+			}
+
+			api.Route("/"+version.Name, func(router chi.Router) {
+				if !version.Deprecation.IsZero() {
+					router.Use(deprecated(version.Deprecation, version.Sunset, successor))
+				}
+				version.Routes(router, s.controller)
+			})
+		}
+	})
+
+	s.router.Group(func(router chi.Router) {
+		router.Use(deprecated(s.legacyRoutesDeprecation, s.legacyRoutesSunset, s.versions[0].Prefix()))
+		legacyRoutes(router, s.controller)
+	})
+}
+
+func deprecated(deprecation time.Time, sunset time.Time, successor string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecation.Unix()))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if successor != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"httpserver/internal/controller"
+	"httpserver/internal/server"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersions_V1RoutesAreNotDeprecated(t *testing.T) {
+	testServer := newTestServer(t)
+
+	resp := postJSON(t, testServer.URL+"/api/v1/user", `{"userName":"JohnDoe","password":"password123"}`)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+	assert.Empty(t, resp.Header.Get("Sunset"))
+}
+
+func TestVersions_V1LoginReturnsVersionedWebsocketUrl(t *testing.T) {
+	testServer := newTestServer(t)
+
+	postJSON(t, testServer.URL+"/api/v1/user", `{"userName":"JohnDoe","password":"password123"}`)
+	resp := postJSON(t, testServer.URL+"/api/v1/user/login", `{"userName":"JohnDoe","password":"password123"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.True(t, strings.HasSuffix(body["url"], "/api/v1/ws"), body["url"])
+	assert.NotEmpty(t, body["wsToken"])
+}
+
+func TestVersions_LegacyAliasesAreDeprecated(t *testing.T) {
+	deprecation := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testServer := newTestServer(t, server.WithLegacyRoutesDeprecation(deprecation), server.WithLegacyRoutesSunset(sunset))
+
+	resp := postJSON(t, testServer.URL+"/user", `{"userName":"JohnDoe","password":"password123"}`)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("@%d", deprecation.Unix()), resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</api/v1>; rel="successor-version"`, resp.Header.Get("Link"))
+}
+
+func TestVersions_V2SideBySide(t *testing.T) {
+	deprecation := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	testServer := newTestServer(t,
+		server.WithVersion(server.Version{
+			Name: "v2",
+			Routes: func(router chi.Router, c *controller.Controller) {
+				router.Get("/user/active/list", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				})
+			},
+		}),
+		server.WithVersionDeprecation("v1", deprecation, sunset),
+	)
+
+	resp, err := http.Get(testServer.URL + "/api/v2/user/active/list")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+
+	resp, err = http.Get(testServer.URL + "/api/v1/user/active/list")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf("@%d", deprecation.Unix()), resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Sat, 01 May 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</api/v2>; rel="successor-version"`, resp.Header.Get("Link"))
+}
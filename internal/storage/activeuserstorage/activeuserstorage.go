@@ -1,47 +1,138 @@
 package activeuserstorage
 
 import (
+	"context"
 	"errors"
 	"httpserver/internal/storage"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 type ActiveUsersStorageInterface interface {
-	Add(*storage.User)
-	Get(string) (*storage.User, error)
-	Delete(user *storage.User)
-	GetNames() []string
+	Add(ctx context.Context, userID string)
+	Get(ctx context.Context, id string) (*storage.User, error)
+	Delete(ctx context.Context, userID string)
+	GetNames(context.Context) []string
+	List(ctx context.Context, query Query) ([]ActiveUser, int)
 }
 
-type ActiveUsersStorage map[string]*storage.User
+// Users resolves the ids of the active users. They are looked up whenever they are read, so a renamed user is listed
+// under the new name and no request shares a user with another.
+type Users interface {
+	GetByID(ctx context.Context, id string) (*storage.User, error)
+}
+
+type ActiveUser struct {
+	User        *storage.User
+	OnlineSince time.Time
+}
 
-func (activeUsersStorage ActiveUsersStorage) Add(user *storage.User) {
-	activeUsersStorage[user.UserName] = user
+// Query selects users whose name starts with Prefix (case-insensitive) and sorts after the After name.
+// A Limit of zero returns every matching user.
+type Query struct {
+	Prefix string
+	After  string
+	Limit  int
 }
 
-func (activeUsersStorage ActiveUsersStorage) Get(userName string) (*storage.User, error) {
-	if userData, ok := activeUsersStorage[userName]; ok {
-		return userData, nil
+// ActiveUsersStorage is safe for concurrent use, connections are registered and released outside of their requests.
+// Users that can no longer be looked up are left out.
+type ActiveUsersStorage struct {
+	mu          sync.Mutex
+	users       Users
+	onlineSince map[string]time.Time
+}
+
+func (activeUsersStorage *ActiveUsersStorage) Add(ctx context.Context, userID string) {
+	activeUsersStorage.mu.Lock()
+	defer activeUsersStorage.mu.Unlock()
+
+	if _, ok := activeUsersStorage.onlineSince[userID]; ok {
+		return
 	}
 
-	return &storage.User{}, errors.New("user does not exist")
+	activeUsersStorage.onlineSince[userID] = time.Now().UTC()
 }
 
-func (activeUsersStorage ActiveUsersStorage) Delete(user *storage.User) {
-	delete(activeUsersStorage, user.UserName)
+func (activeUsersStorage *ActiveUsersStorage) Get(ctx context.Context, id string) (*storage.User, error) {
+	activeUsersStorage.mu.Lock()
+	_, ok := activeUsersStorage.onlineSince[id]
+	activeUsersStorage.mu.Unlock()
+	if !ok {
+		return &storage.User{}, errors.New("user does not exist")
+	}
+
+	return activeUsersStorage.users.GetByID(ctx, id)
 }
 
-func (activeUsersStorage ActiveUsersStorage) GetNames() []string {
-	userNames := make([]string, len(activeUsersStorage))
+func (activeUsersStorage *ActiveUsersStorage) Delete(ctx context.Context, userID string) {
+	activeUsersStorage.mu.Lock()
+	defer activeUsersStorage.mu.Unlock()
+
+	delete(activeUsersStorage.onlineSince, userID)
+}
 
-	i := 0
-	for k := range activeUsersStorage {
-		userNames[i] = k
-		i++
+func (activeUsersStorage *ActiveUsersStorage) GetNames(ctx context.Context) []string {
+	activeUsers := activeUsersStorage.all(ctx)
+	userNames := make([]string, len(activeUsers))
+	for i, activeUser := range activeUsers {
+		userNames[i] = activeUser.User.UserName
 	}
 
 	return userNames
 }
 
-func NewActiveUsersStorage() ActiveUsersStorageInterface {
-	return &ActiveUsersStorage{}
+// List returns a page of active users ordered by username along with the number of users matching the prefix.
+func (activeUsersStorage *ActiveUsersStorage) List(ctx context.Context, query Query) ([]ActiveUser, int) {
+	prefix := strings.ToLower(query.Prefix)
+	activeUsers := activeUsersStorage.all(ctx)
+	matching := make([]ActiveUser, 0, len(activeUsers))
+	for _, activeUser := range activeUsers {
+		if strings.HasPrefix(strings.ToLower(activeUser.User.UserName), prefix) {
+			matching = append(matching, activeUser)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].User.UserName < matching[j].User.UserName
+	})
+
+	page := matching
+	if query.After != "" {
+		start := sort.Search(len(matching), func(i int) bool {
+			return matching[i].User.UserName > query.After
+		})
+		page = matching[start:]
+	}
+	if query.Limit > 0 && len(page) > query.Limit {
+		page = page[:query.Limit]
+	}
+
+	return page, len(matching)
+}
+
+// all looks the active users up without holding the lock.
+func (activeUsersStorage *ActiveUsersStorage) all(ctx context.Context) []ActiveUser {
+	activeUsersStorage.mu.Lock()
+	onlineSince := make(map[string]time.Time, len(activeUsersStorage.onlineSince))
+	for id, since := range activeUsersStorage.onlineSince {
+		onlineSince[id] = since
+	}
+	activeUsersStorage.mu.Unlock()
+
+	activeUsers := make([]ActiveUser, 0, len(onlineSince))
+	for id, since := range onlineSince {
+		user, err := activeUsersStorage.users.GetByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		activeUsers = append(activeUsers, ActiveUser{User: user, OnlineSince: since})
+	}
+
+	return activeUsers
+}
+
+func NewActiveUsersStorage(users Users) ActiveUsersStorageInterface {
+	return &ActiveUsersStorage{users: users, onlineSince: map[string]time.Time{}}
 }
@@ -1,97 +1,170 @@
 package activeuserstorage_test
 
 import (
+	"context"
 	"httpserver/internal/storage"
 	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/userstorage"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestActiveUsersStorage_Add(t *testing.T) {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
+func newStorages(t testing.TB, names ...string) (userstorage.UserStorageInterface, activeuserstorage.ActiveUsersStorageInterface, []string) {
+	users := userstorage.NewUserStorage()
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, err := users.Add(context.Background(), name, "password123")
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
 
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
+	return users, activeuserstorage.NewActiveUsersStorage(users), ids
+}
+
+func TestActiveUsersStorage_Add(t *testing.T) {
+	users, activeUsersStorage, ids := newStorages(t, "JohnDoe")
 
-	activeUsersStorage.Add(user)
-	userInStorage, _ := activeUsersStorage.Get("JohnDoe")
+	activeUsersStorage.Add(context.Background(), ids[0])
+	userInStorage, _ := activeUsersStorage.Get(context.Background(), ids[0])
+	user, _ := users.GetByID(context.Background(), ids[0])
 	assert.Equal(t, user, userInStorage)
 }
 
 func TestActiveUsersStorage_Get(t *testing.T) {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
+	_, activeUsersStorage, ids := newStorages(t, "JohnDoe")
 
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
+	activeUsersStorage.Add(context.Background(), ids[0])
 
-	activeUsersStorage.Add(user)
-
-	resultUser, err := activeUsersStorage.Get("JohnDoe")
+	resultUser, err := activeUsersStorage.Get(context.Background(), ids[0])
 
 	assert.NoError(t, err)
-	assert.Equal(t, user, resultUser)
+	assert.Equal(t, "JohnDoe", resultUser.UserName)
 }
 
 func TestActiveUsersStorage_Get_NonExistentUser(t *testing.T) {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
+	_, activeUsersStorage, _ := newStorages(t)
 
-	user, err := activeUsersStorage.Get("NonExistentUser")
+	user, err := activeUsersStorage.Get(context.Background(), "NonExistentUser")
 
 	assert.Error(t, err)
 	assert.Equal(t, &storage.User{}, user)
 }
 
 func TestActiveUsersStorage_Delete(t *testing.T) {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
-
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
+	_, activeUsersStorage, ids := newStorages(t, "JohnDoe")
 
-	activeUsersStorage.Add(user)
-	activeUsersStorage.Delete(user)
+	activeUsersStorage.Add(context.Background(), ids[0])
+	activeUsersStorage.Delete(context.Background(), ids[0])
 
-	_, err := activeUsersStorage.Get("JohnDoe")
+	_, err := activeUsersStorage.Get(context.Background(), ids[0])
 
 	assert.Error(t, err)
 }
 
-func TestActiveUsersStorage_GetNames(t *testing.T) {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
+func TestActiveUsersStorage_RenamedUser(t *testing.T) {
+	users, activeUsersStorage, ids := newStorages(t, "JohnDoe")
+	activeUsersStorage.Add(context.Background(), ids[0])
+
+	users.Update(context.Background(), &storage.User{UserName: "Johnny", Password: "password123", Uuid: ids[0]})
 
-	user1 := &storage.User{UserName: "JohnDoe", Password: "password123"}
-	user2 := &storage.User{UserName: "JaneSmith", Password: "password456"}
+	assert.Equal(t, []string{"Johnny"}, activeUsersStorage.GetNames(context.Background()))
+	activeUsersStorage.Delete(context.Background(), ids[0])
+	assert.Empty(t, activeUsersStorage.GetNames(context.Background()))
+}
+
+func TestActiveUsersStorage_DeletedUserIsLeftOut(t *testing.T) {
+	users, activeUsersStorage, ids := newStorages(t, "JohnDoe", "JaneSmith")
+	activeUsersStorage.Add(context.Background(), ids[0])
+	activeUsersStorage.Add(context.Background(), ids[1])
 
-	activeUsersStorage.Add(user1)
-	activeUsersStorage.Add(user2)
+	users.Delete(context.Background(), ids[0])
+
+	activeUsers, total := activeUsersStorage.List(context.Background(), activeuserstorage.Query{})
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []string{"JaneSmith"}, userNames(activeUsers))
+}
+
+func TestActiveUsersStorage_GetNames(t *testing.T) {
+	_, activeUsersStorage, ids := newStorages(t, "JohnDoe", "JaneSmith")
+
+	activeUsersStorage.Add(context.Background(), ids[0])
+	activeUsersStorage.Add(context.Background(), ids[1])
 
 	expectedNames := []string{"JohnDoe", "JaneSmith"}
-	userNames := activeUsersStorage.GetNames()
+	userNames := activeUsersStorage.GetNames(context.Background())
 
 	assert.ElementsMatch(t, expectedNames, userNames)
 }
 
-func BenchmarkAdd(b *testing.B) {
-	activeUserStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{
-		UserName: "testuser",
+func TestActiveUsersStorage_List(t *testing.T) {
+	users, activeUsersStorage, ids := newStorages(t, "JohnDoe", "JaneSmith")
+	activeUsersStorage.Add(context.Background(), ids[0])
+	activeUsersStorage.Add(context.Background(), ids[1])
+
+	activeUsers, total := activeUsersStorage.List(context.Background(), activeuserstorage.Query{})
+	assert.Equal(t, 2, total)
+	assert.Len(t, activeUsers, 2)
+	jane, _ := users.GetByID(context.Background(), ids[1])
+	john, _ := users.GetByID(context.Background(), ids[0])
+	assert.Equal(t, jane, activeUsers[0].User)
+	assert.Equal(t, john, activeUsers[1].User)
+	assert.False(t, activeUsers[0].OnlineSince.IsZero())
+}
+
+func TestActiveUsersStorage_List_PrefixAndCursor(t *testing.T) {
+	_, activeUsersStorage, ids := newStorages(t, "jane", "Jack", "john", "bob")
+	for _, id := range ids {
+		activeUsersStorage.Add(context.Background(), id)
 	}
 
+	activeUsers, total := activeUsersStorage.List(context.Background(), activeuserstorage.Query{Prefix: "J", Limit: 2})
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"Jack", "jane"}, userNames(activeUsers))
+
+	activeUsers, total = activeUsersStorage.List(context.Background(), activeuserstorage.Query{Prefix: "J", After: "jane", Limit: 2})
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"john"}, userNames(activeUsers))
+}
+
+func TestActiveUsersStorage_Add_KeepsOnlineSince(t *testing.T) {
+	_, activeUsersStorage, ids := newStorages(t, "JohnDoe")
+	activeUsersStorage.Add(context.Background(), ids[0])
+	first, _ := activeUsersStorage.List(context.Background(), activeuserstorage.Query{})
+
+	activeUsersStorage.Add(context.Background(), ids[0])
+	second, _ := activeUsersStorage.List(context.Background(), activeuserstorage.Query{})
+
+	assert.Equal(t, first[0].OnlineSince, second[0].OnlineSince)
+}
+
+func userNames(activeUsers []activeuserstorage.ActiveUser) []string {
+	names := make([]string, 0, len(activeUsers))
+	for _, activeUser := range activeUsers {
+		names = append(names, activeUser.User.UserName)
+	}
+
+	return names
+}
+
+func BenchmarkAdd(b *testing.B) {
+	_, activeUserStorage, ids := newStorages(b, "testuser")
+
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		activeUserStorage.Add(user)
+		activeUserStorage.Add(context.Background(), ids[0])
 	}
 }
 
 func BenchmarkGet(b *testing.B) {
-	activeUserStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{
-		UserName: "testuser",
-	}
-	activeUserStorage.Add(user)
+	_, activeUserStorage, ids := newStorages(b, "testuser")
+	activeUserStorage.Add(context.Background(), ids[0])
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, err := activeUserStorage.Get("testuser")
+		_, err := activeUserStorage.Get(context.Background(), ids[0])
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -99,30 +172,23 @@ func BenchmarkGet(b *testing.B) {
 }
 
 func BenchmarkDelete(b *testing.B) {
-	activeUserStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{
-		UserName: "testuser",
-	}
-	activeUserStorage.Add(user)
+	_, activeUserStorage, ids := newStorages(b, "testuser")
+	activeUserStorage.Add(context.Background(), ids[0])
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		activeUserStorage.Delete(user)
+		activeUserStorage.Delete(context.Background(), ids[0])
 	}
 }
 
 func BenchmarkGetNames(b *testing.B) {
-	activeUserStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{
-		UserName: "testuser",
-		// Set other user properties if needed
-	}
-	activeUserStorage.Add(user)
+	_, activeUserStorage, ids := newStorages(b, "testuser")
+	activeUserStorage.Add(context.Background(), ids[0])
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_ = activeUserStorage.GetNames()
+		_ = activeUserStorage.GetNames(context.Background())
 	}
 }
@@ -1,52 +1,61 @@
 package activeuserstorage_test
 
 import (
+	"context"
 	"fmt"
-	"httpserver/internal/storage"
 	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/userstorage"
+	"sort"
 )
 
 func ExampleActiveUsersStorage_Add() {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(users)
+	id, _ := users.Add(context.Background(), "john.doe", "password")
 
-	activeUsersStorage.Add(user)
-	user, _ = activeUsersStorage.Get("john.doe")
-	fmt.Println(user)
+	activeUsersStorage.Add(context.Background(), id)
+	user, _ := activeUsersStorage.Get(context.Background(), id)
+	fmt.Println(user.UserName)
 
-	// Output: &{john.doe password }
+	// Output: john.doe
 }
 
 func ExampleActiveUsersStorage_Get() {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
-	activeUsersStorage.Add(user)
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(users)
+	id, _ := users.Add(context.Background(), "john.doe", "password")
+	activeUsersStorage.Add(context.Background(), id)
 
-	user, _ = activeUsersStorage.Get("john.doe")
-	fmt.Println(user)
+	user, _ := activeUsersStorage.Get(context.Background(), id)
+	fmt.Println(user.UserName)
 
-	// Output: &{john.doe password }
+	// Output: john.doe
 }
 
 func ExampleActiveUsersStorage_Delete() {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
-	activeUsersStorage.Add(user)
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(users)
+	id, _ := users.Add(context.Background(), "john.doe", "password")
+	activeUsersStorage.Add(context.Background(), id)
 
-	activeUsersStorage.Delete(user)
-	fmt.Println(activeUsersStorage.Get("john.doe"))
+	activeUsersStorage.Delete(context.Background(), id)
+	_, err := activeUsersStorage.Get(context.Background(), id)
+	fmt.Println(err)
 
-	// Output: &{  } user does not exist
+	// Output: user does not exist
 }
 
 func ExampleActiveUsersStorage_GetNames() {
-	activeUsersStorage := activeuserstorage.NewActiveUsersStorage()
-	user1 := &storage.User{UserName: "john.doe", Password: "password"}
-	user2 := &storage.User{UserName: "jane.doe", Password: "password"}
-	activeUsersStorage.Add(user1)
-	activeUsersStorage.Add(user2)
-
-	fmt.Println(activeUsersStorage.GetNames())
-
-	// Output: [john.doe jane.doe]
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := activeuserstorage.NewActiveUsersStorage(users)
+	john, _ := users.Add(context.Background(), "john.doe", "password")
+	jane, _ := users.Add(context.Background(), "jane.doe", "password")
+	activeUsersStorage.Add(context.Background(), john)
+	activeUsersStorage.Add(context.Background(), jane)
+
+	names := activeUsersStorage.GetNames(context.Background())
+	sort.Strings(names)
+	fmt.Println(names)
+
+	// Output: [jane.doe john.doe]
 }
@@ -0,0 +1,20 @@
+package resettokenstorage_test
+
+import (
+	"context"
+	"fmt"
+	"httpserver/internal/storage/resettokenstorage"
+	"time"
+)
+
+func ExampleResetTokenStorage_Get() {
+	resetTokenStorage := resettokenstorage.NewResetTokenStorage()
+	resetTokenStorage.Add(context.Background(), "token123", "user-1", time.Now().Add(time.Minute))
+
+	fmt.Println(resetTokenStorage.Get(context.Background(), "token123"))
+	fmt.Println(resetTokenStorage.Get(context.Background(), "token123"))
+
+	// Output:
+	// user-1 <nil>
+	//  reset token does not exist
+}
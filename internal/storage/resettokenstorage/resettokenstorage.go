@@ -0,0 +1,73 @@
+package resettokenstorage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ResetTokenStorageInterface keeps the id of the user a reset token was issued to.
+type ResetTokenStorageInterface interface {
+	Add(ctx context.Context, token string, userID string, expiresAt time.Time)
+	Get(ctx context.Context, token string) (string, error)
+	DeleteByUser(ctx context.Context, userID string)
+}
+
+type resetToken struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// ResetTokenStorage is safe for concurrent use, so a reset token is consumed by a single request.
+type ResetTokenStorage struct {
+	mu     sync.Mutex
+	tokens map[string]resetToken
+}
+
+// Add replaces the previous token of the user, only the latest reset link works, and drops the expired tokens.
+func (resetTokenStorage *ResetTokenStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	resetTokenStorage.mu.Lock()
+	defer resetTokenStorage.mu.Unlock()
+
+	now := time.Now()
+	for existing, entry := range resetTokenStorage.tokens {
+		if entry.userID == userID || now.After(entry.expiresAt) {
+			delete(resetTokenStorage.tokens, existing)
+		}
+	}
+
+	resetTokenStorage.tokens[token] = resetToken{userID: userID, expiresAt: expiresAt}
+}
+
+func (resetTokenStorage *ResetTokenStorage) Get(ctx context.Context, token string) (string, error) {
+	resetTokenStorage.mu.Lock()
+	defer resetTokenStorage.mu.Unlock()
+
+	entry, ok := resetTokenStorage.tokens[token]
+	if !ok {
+		return "", errors.New("reset token does not exist")
+	}
+
+	delete(resetTokenStorage.tokens, token)
+	if time.Now().After(entry.expiresAt) {
+		return "", errors.New("reset token expired")
+	}
+
+	return entry.userID, nil
+}
+
+func (resetTokenStorage *ResetTokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	resetTokenStorage.mu.Lock()
+	defer resetTokenStorage.mu.Unlock()
+
+	for token, entry := range resetTokenStorage.tokens {
+		if entry.userID == userID {
+			delete(resetTokenStorage.tokens, token)
+		}
+	}
+}
+
+func NewResetTokenStorage() ResetTokenStorageInterface {
+	return &ResetTokenStorage{tokens: map[string]resetToken{}}
+}
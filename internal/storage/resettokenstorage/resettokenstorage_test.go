@@ -0,0 +1,61 @@
+package resettokenstorage_test
+
+import (
+	"context"
+	"httpserver/internal/storage/resettokenstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResetTokenStorage_Get(t *testing.T) {
+	resetTokenStorage := resettokenstorage.NewResetTokenStorage()
+	resetTokenStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Minute))
+
+	userID, err := resetTokenStorage.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", userID)
+
+	_, err = resetTokenStorage.Get(context.Background(), "abc123")
+	assert.EqualError(t, err, "reset token does not exist")
+}
+
+func TestResetTokenStorage_Get_Expired(t *testing.T) {
+	resetTokenStorage := resettokenstorage.NewResetTokenStorage()
+	resetTokenStorage.Add(context.Background(), "abc123", "1", time.Now().Add(-time.Minute))
+
+	userID, err := resetTokenStorage.Get(context.Background(), "abc123")
+
+	assert.EqualError(t, err, "reset token expired")
+	assert.Empty(t, userID)
+}
+
+func TestResetTokenStorage_DeleteByUser(t *testing.T) {
+	resetTokenStorage := resettokenstorage.NewResetTokenStorage()
+	resetTokenStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Minute))
+	resetTokenStorage.Add(context.Background(), "def456", "2", time.Now().Add(time.Minute))
+
+	resetTokenStorage.DeleteByUser(context.Background(), "1")
+
+	_, err := resetTokenStorage.Get(context.Background(), "abc123")
+	assert.Error(t, err)
+	_, err = resetTokenStorage.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+}
+
+func TestResetTokenStorage_Add_ReplacesPreviousToken(t *testing.T) {
+	resetTokenStorage := resettokenstorage.NewResetTokenStorage()
+	resetTokenStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Minute))
+	resetTokenStorage.Add(context.Background(), "expired", "2", time.Now().Add(-time.Minute))
+
+	resetTokenStorage.Add(context.Background(), "def456", "1", time.Now().Add(time.Minute))
+
+	_, err := resetTokenStorage.Get(context.Background(), "abc123")
+	assert.EqualError(t, err, "reset token does not exist")
+	_, err = resetTokenStorage.Get(context.Background(), "expired")
+	assert.EqualError(t, err, "reset token does not exist")
+	userID, err := resetTokenStorage.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", userID)
+}
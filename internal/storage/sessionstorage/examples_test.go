@@ -0,0 +1,29 @@
+package sessionstorage_test
+
+import (
+	"context"
+	"fmt"
+	"httpserver/internal/storage/sessionstorage"
+	"time"
+)
+
+func ExampleSessionStorage_Get() {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "token123", "user-1", time.Now().Add(time.Hour))
+
+	userID, _ := sessionStorage.Get(context.Background(), "token123")
+	fmt.Println(userID)
+
+	// Output: user-1
+}
+
+func ExampleSessionStorage_Delete() {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "token123", "user-1", time.Now().Add(time.Hour))
+
+	sessionStorage.Delete(context.Background(), "token123")
+
+	fmt.Println(sessionStorage.Get(context.Background(), "token123"))
+
+	// Output: session does not exist
+}
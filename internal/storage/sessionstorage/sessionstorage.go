@@ -0,0 +1,81 @@
+package sessionstorage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SessionStorageInterface keeps the id of the user a session belongs to, the user is looked up on every request so
+// changes such as a ban apply to the open sessions.
+type SessionStorageInterface interface {
+	Add(ctx context.Context, token string, userID string, expiresAt time.Time)
+	Get(ctx context.Context, token string) (string, error)
+	Delete(ctx context.Context, token string)
+	DeleteByUser(ctx context.Context, userID string)
+}
+
+type session struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// SessionStorage is safe for concurrent use.
+type SessionStorage struct {
+	mu       sync.Mutex
+	sessions map[string]session
+}
+
+// Add also drops the expired sessions, so sessions that are never used again do not pile up.
+func (sessionStorage *SessionStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	sessionStorage.mu.Lock()
+	defer sessionStorage.mu.Unlock()
+
+	now := time.Now()
+	for existing, entry := range sessionStorage.sessions {
+		if now.After(entry.expiresAt) {
+			delete(sessionStorage.sessions, existing)
+		}
+	}
+
+	sessionStorage.sessions[token] = session{userID: userID, expiresAt: expiresAt}
+}
+
+func (sessionStorage *SessionStorage) Get(ctx context.Context, token string) (string, error) {
+	sessionStorage.mu.Lock()
+	defer sessionStorage.mu.Unlock()
+
+	entry, ok := sessionStorage.sessions[token]
+	if !ok {
+		return "", errors.New("session does not exist")
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(sessionStorage.sessions, token)
+		return "", errors.New("session expired")
+	}
+
+	return entry.userID, nil
+}
+
+func (sessionStorage *SessionStorage) Delete(ctx context.Context, token string) {
+	sessionStorage.mu.Lock()
+	defer sessionStorage.mu.Unlock()
+
+	delete(sessionStorage.sessions, token)
+}
+
+func (sessionStorage *SessionStorage) DeleteByUser(ctx context.Context, userID string) {
+	sessionStorage.mu.Lock()
+	defer sessionStorage.mu.Unlock()
+
+	for token, entry := range sessionStorage.sessions {
+		if entry.userID == userID {
+			delete(sessionStorage.sessions, token)
+		}
+	}
+}
+
+func NewSessionStorage() SessionStorageInterface {
+	return &SessionStorage{sessions: map[string]session{}}
+}
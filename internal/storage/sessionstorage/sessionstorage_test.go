@@ -0,0 +1,83 @@
+package sessionstorage_test
+
+import (
+	"context"
+	"httpserver/internal/storage/sessionstorage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionStorage_Get(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+
+	sessionStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Hour))
+
+	userID, err := sessionStorage.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", userID)
+
+	userID, err = sessionStorage.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", userID)
+}
+
+func TestSessionStorage_Get_NonExistentSession(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+
+	userID, err := sessionStorage.Get(context.Background(), "abc123")
+
+	assert.EqualError(t, err, "session does not exist")
+	assert.Empty(t, userID)
+}
+
+func TestSessionStorage_Delete(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Hour))
+
+	sessionStorage.Delete(context.Background(), "abc123")
+
+	_, err := sessionStorage.Get(context.Background(), "abc123")
+	assert.Error(t, err)
+}
+
+func TestSessionStorage_DeleteByUser(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "abc123", "1", time.Now().Add(time.Hour))
+	sessionStorage.Add(context.Background(), "def456", "1", time.Now().Add(time.Hour))
+	sessionStorage.Add(context.Background(), "ghi789", "2", time.Now().Add(time.Hour))
+
+	sessionStorage.DeleteByUser(context.Background(), "1")
+
+	_, err := sessionStorage.Get(context.Background(), "abc123")
+	assert.Error(t, err)
+	_, err = sessionStorage.Get(context.Background(), "def456")
+	assert.Error(t, err)
+	userID, err := sessionStorage.Get(context.Background(), "ghi789")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", userID)
+}
+
+func TestSessionStorage_Get_ExpiredSession(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "abc123", "1", time.Now().Add(-time.Second))
+
+	_, err := sessionStorage.Get(context.Background(), "abc123")
+	assert.EqualError(t, err, "session expired")
+
+	_, err = sessionStorage.Get(context.Background(), "abc123")
+	assert.EqualError(t, err, "session does not exist")
+}
+
+func TestSessionStorage_Add_PrunesExpiredSessions(t *testing.T) {
+	sessionStorage := sessionstorage.NewSessionStorage()
+	sessionStorage.Add(context.Background(), "abc123", "1", time.Now().Add(-time.Second))
+
+	sessionStorage.Add(context.Background(), "def456", "2", time.Now().Add(time.Hour))
+
+	_, err := sessionStorage.Get(context.Background(), "abc123")
+	assert.EqualError(t, err, "session does not exist")
+	_, err = sessionStorage.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+}
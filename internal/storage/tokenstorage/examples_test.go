@@ -1,41 +1,38 @@
 package tokenstorage_test
 
 import (
+	"context"
 	"fmt"
-	"httpserver/internal/storage"
 	"httpserver/internal/storage/tokenstorage"
 )
 
 func ExampleTokenStorage_Add() {
 	tokenStorage := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
 
-	tokenStorage.Add("token123", user)
-	user, _ = tokenStorage.Get("token123")
-	fmt.Println(user)
+	tokenStorage.Add(context.Background(), "token123", "user-1")
+	userID, _ := tokenStorage.Get(context.Background(), "token123")
+	fmt.Println(userID)
 
-	// Output: &{john.doe password }
+	// Output: user-1
 }
 
 func ExampleTokenStorage_Get() {
 	tokenStorage := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
-	tokenStorage.Add("token123", user)
+	tokenStorage.Add(context.Background(), "token123", "user-1")
 
-	user, _ = tokenStorage.Get("token123")
-	fmt.Println(user)
+	userID, _ := tokenStorage.Get(context.Background(), "token123")
+	fmt.Println(userID)
 
-	// Output: &{john.doe password }
+	// Output: user-1
 }
 
 func ExampleTokenStorage_Delete() {
 	tokenStorage := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "john.doe", Password: "password"}
-	tokenStorage.Add("token123", user)
+	tokenStorage.Add(context.Background(), "token123", "user-1")
 
-	tokenStorage.Delete("token123")
+	tokenStorage.Delete(context.Background(), "token123")
 
-	fmt.Println(tokenStorage.Get("token123"))
+	fmt.Println(tokenStorage.Get(context.Background(), "token123"))
 
-	// Output: &{  } user does not exist
+	// Output: user does not exist
 }
@@ -1,35 +1,63 @@
 package tokenstorage
 
 import (
+	"context"
 	"errors"
-	"httpserver/internal/storage"
+	"sync"
 )
 
+// TokenStorageInterface keeps the id of the user a token was issued to, the user is looked up when the token is used
+// so it is never shared between requests.
 type TokenStorageInterface interface {
-	Add(string, *storage.User)
-	Get(string) (*storage.User, error)
-	Delete(token string)
+	Add(ctx context.Context, token string, userID string)
+	Get(ctx context.Context, token string) (string, error)
+	Delete(ctx context.Context, token string)
+	DeleteByUser(ctx context.Context, userID string)
 }
 
-type TokenStorage map[string]*storage.User
+// TokenStorage is safe for concurrent use, so a one-time token is consumed by a single request.
+type TokenStorage struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (tokenStorage *TokenStorage) Add(ctx context.Context, token string, userID string) {
+	tokenStorage.mu.Lock()
+	defer tokenStorage.mu.Unlock()
 
-func (tokenStorage TokenStorage) Add(token string, user *storage.User) {
-	tokenStorage[token] = user
+	tokenStorage.tokens[token] = userID
 }
 
-func (tokenStorage TokenStorage) Get(token string) (*storage.User, error) {
-	if userData, ok := tokenStorage[token]; ok {
-		delete(tokenStorage, token)
-		return userData, nil
+func (tokenStorage *TokenStorage) Get(ctx context.Context, token string) (string, error) {
+	tokenStorage.mu.Lock()
+	defer tokenStorage.mu.Unlock()
+
+	if userID, ok := tokenStorage.tokens[token]; ok {
+		delete(tokenStorage.tokens, token)
+		return userID, nil
 	}
 
-	return &storage.User{}, errors.New("user does not exist")
+	return "", errors.New("user does not exist")
+}
+
+func (tokenStorage *TokenStorage) Delete(ctx context.Context, token string) {
+	tokenStorage.mu.Lock()
+	defer tokenStorage.mu.Unlock()
+
+	delete(tokenStorage.tokens, token)
 }
 
-func (tokenStorage TokenStorage) Delete(token string) {
-	delete(tokenStorage, token)
+func (tokenStorage *TokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	tokenStorage.mu.Lock()
+	defer tokenStorage.mu.Unlock()
+
+	for token, tokenUserID := range tokenStorage.tokens {
+		if tokenUserID == userID {
+			delete(tokenStorage.tokens, token)
+		}
+	}
 }
 
 func NewTokenStorage() TokenStorageInterface {
-	return &TokenStorage{}
+	return &TokenStorage{tokens: map[string]string{}}
 }
@@ -1,7 +1,7 @@
 package tokenstorage_test
 
 import (
-	"httpserver/internal/storage"
+	"context"
 	"httpserver/internal/storage/tokenstorage"
 	"testing"
 
@@ -11,29 +11,27 @@ import (
 func TestTokenStorage_Add(t *testing.T) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
 
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
 	token := "abc123"
 
-	tokenStorageInstance.Add(token, user)
+	tokenStorageInstance.Add(context.Background(), token, "1")
 
-	userInStorage, _ := tokenStorageInstance.Get(token)
-	assert.Equal(t, user, userInStorage)
+	userID, _ := tokenStorageInstance.Get(context.Background(), token)
+	assert.Equal(t, "1", userID)
 }
 
 func TestTokenStorage_Get(t *testing.T) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
 
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
 	token := "abc123"
 
-	tokenStorageInstance.Add(token, user)
+	tokenStorageInstance.Add(context.Background(), token, "1")
 
-	resultUser, err := tokenStorageInstance.Get(token)
+	userID, err := tokenStorageInstance.Get(context.Background(), token)
 
 	assert.NoError(t, err)
-	assert.Equal(t, user, resultUser)
+	assert.Equal(t, "1", userID)
 
-	_, err = tokenStorageInstance.Get(token)
+	_, err = tokenStorageInstance.Get(context.Background(), token)
 
 	assert.Error(t, err)
 }
@@ -41,14 +39,13 @@ func TestTokenStorage_Get(t *testing.T) {
 func TestTokenStorage_Delete(t *testing.T) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
 
-	user := &storage.User{UserName: "JohnDoe", Password: "password123"}
 	token := "abc123"
 
-	tokenStorageInstance.Add(token, user)
+	tokenStorageInstance.Add(context.Background(), token, "1")
 
-	tokenStorageInstance.Delete(token)
+	tokenStorageInstance.Delete(context.Background(), token)
 
-	_, err := tokenStorageInstance.Get(token)
+	_, err := tokenStorageInstance.Get(context.Background(), token)
 
 	assert.Error(t, err)
 }
@@ -56,40 +53,51 @@ func TestTokenStorage_Delete(t *testing.T) {
 func TestTokenStorage_Get_NonExistentToken(t *testing.T) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
 
-	user, err := tokenStorageInstance.Get("nonexistent-token")
+	userID, err := tokenStorageInstance.Get(context.Background(), "nonexistent-token")
 
 	assert.Error(t, err)
-	assert.Equal(t, &storage.User{}, user)
+	assert.Empty(t, userID)
 }
 
 func BenchmarkAdd(b *testing.B) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "John Doe"}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		tokenStorageInstance.Add("token", user)
+		tokenStorageInstance.Add(context.Background(), "token", "1")
 	}
 }
 
 func BenchmarkGet(b *testing.B) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "John Doe"}
-	tokenStorageInstance.Add("token", user)
+	tokenStorageInstance.Add(context.Background(), "token", "1")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		tokenStorageInstance.Get("token")
+		tokenStorageInstance.Get(context.Background(), "token")
 	}
 }
 
 func BenchmarkDelete(b *testing.B) {
 	tokenStorageInstance := tokenstorage.NewTokenStorage()
-	user := &storage.User{UserName: "John Doe"}
-	tokenStorageInstance.Add("token", user)
+	tokenStorageInstance.Add(context.Background(), "token", "1")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		tokenStorageInstance.Delete("token")
+		tokenStorageInstance.Delete(context.Background(), "token")
 	}
 }
+
+func TestTokenStorage_DeleteByUser(t *testing.T) {
+	tokenStorageInstance := tokenstorage.NewTokenStorage()
+	tokenStorageInstance.Add(context.Background(), "abc123", "1")
+	tokenStorageInstance.Add(context.Background(), "def456", "2")
+
+	tokenStorageInstance.DeleteByUser(context.Background(), "1")
+
+	_, err := tokenStorageInstance.Get(context.Background(), "abc123")
+	assert.Error(t, err)
+	userID, err := tokenStorageInstance.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", userID)
+}
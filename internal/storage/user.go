@@ -1,7 +1,13 @@
 package storage
 
 type User struct {
-	UserName string
-	Password string
-	Uuid     string
+	UserName    string
+	Password    string
+	Uuid        string
+	DisplayName string
+	AvatarUrl   string
+	Bio         string
+	StatusText  string
+	Roles       []string
+	Banned      bool
 }
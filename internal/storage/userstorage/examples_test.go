@@ -1,22 +1,23 @@
 package userstorage_test
 
 import (
+	"context"
 	"httpserver/internal/storage/userstorage"
 )
 
 func ExampleUserStorage_Add() {
 	storage := userstorage.NewUserStorage()
 
-	storage.Add("john.doe", "password")
+	storage.Add(context.Background(), "john.doe", "password")
 
-	user, _ := storage.Get("john.doe")
+	user, _ := storage.Get(context.Background(), "john.doe")
 	_ = user
 }
 
 func ExampleUserStorage_Get() {
 	storage := userstorage.NewUserStorage()
-	storage.Add("john.doe", "password")
+	storage.Add(context.Background(), "john.doe", "password")
 
-	user, _ := storage.Get("john.doe")
+	user, _ := storage.Get(context.Background(), "john.doe")
 	_ = user
 }
@@ -1,35 +1,153 @@
 package userstorage
 
 import (
+	"context"
 	"errors"
 	"httpserver/internal/storage"
+	"sort"
+	"sync"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUserNotFound  = errors.New("user does not exist")
+	ErrUserNameTaken = errors.New("username is already taken")
+)
+
 type UserStorageInterface interface {
-	Add(string, string) string
-	Get(string) (*storage.User, error)
+	Add(ctx context.Context, userName string, password string) (string, error)
+	Get(ctx context.Context, userName string) (*storage.User, error)
+	GetByID(ctx context.Context, id string) (*storage.User, error)
+	Update(ctx context.Context, user *storage.User) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, offset int, limit int) ([]*storage.User, int)
+}
+
+// UserStorage keeps users by UUID, the username is a unique alias resolved through a secondary index. It is safe for
+// concurrent use, users are copied in and out so a caller never shares one with another request.
+type UserStorage struct {
+	mu    sync.Mutex
+	users map[string]*storage.User
+	names map[string]string
+}
+
+func (userStorage *UserStorage) Add(ctx context.Context, userName string, password string) (string, error) {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	if _, ok := userStorage.names[userName]; ok {
+		return "", ErrUserNameTaken
+	}
+
+	id := uuid.New().String()
+	userStorage.users[id] = &storage.User{UserName: userName, Password: password, Uuid: id}
+	userStorage.names[userName] = id
+
+	return id, nil
+}
+
+func (userStorage *UserStorage) Get(ctx context.Context, userName string) (*storage.User, error) {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	if id, ok := userStorage.names[userName]; ok {
+		return userStorage.getByID(id)
+	}
+
+	return &storage.User{}, ErrUserNotFound
+}
+
+func (userStorage *UserStorage) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	return userStorage.getByID(id)
+}
+
+func (userStorage *UserStorage) getByID(id string) (*storage.User, error) {
+	if user, ok := userStorage.users[id]; ok {
+		return clone(user), nil
+	}
+
+	return &storage.User{}, ErrUserNotFound
 }
 
-type UserStorage map[string]*storage.User
+// Update replaces the stored user, the users returned before keep their previous values.
+func (userStorage *UserStorage) Update(ctx context.Context, user *storage.User) error {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	existing, ok := userStorage.users[user.Uuid]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if id, ok := userStorage.names[user.UserName]; ok && id != user.Uuid {
+		return ErrUserNameTaken
+	}
 
-func (userStorage UserStorage) Add(userName string, password string) string {
-	id := uuid.New()
-	user := &storage.User{UserName: userName, Password: password, Uuid: id.String()}
-	userStorage[userName] = user
+	delete(userStorage.names, existing.UserName)
+	userStorage.names[user.UserName] = user.Uuid
+	userStorage.users[user.Uuid] = clone(user)
 
-	return id.String()
+	return nil
 }
 
-func (userStorage UserStorage) Get(userName string) (*storage.User, error) {
-	if user, ok := userStorage[userName]; ok {
-		return user, nil
+func (userStorage *UserStorage) Delete(ctx context.Context, id string) error {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	existing, ok := userStorage.users[id]
+	if !ok {
+		return ErrUserNotFound
 	}
 
-	return &storage.User{}, errors.New("user does not exist")
+	delete(userStorage.names, existing.UserName)
+	delete(userStorage.users, id)
+
+	return nil
+}
+
+// List returns a page of users ordered by username along with the total number of users.
+func (userStorage *UserStorage) List(ctx context.Context, offset int, limit int) ([]*storage.User, int) {
+	userStorage.mu.Lock()
+	defer userStorage.mu.Unlock()
+
+	names := make([]string, 0, len(userStorage.names))
+	for name := range userStorage.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := len(names)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	if limit <= 0 || offset+limit > total {
+		limit = total - offset
+	}
+
+	users := make([]*storage.User, 0, limit)
+	for _, name := range names[offset : offset+limit] {
+		users = append(users, clone(userStorage.users[userStorage.names[name]]))
+	}
+
+	return users, total
+}
+
+func clone(user *storage.User) *storage.User {
+	copied := *user
+	copied.Roles = append([]string(nil), user.Roles...)
+
+	return &copied
 }
 
 func NewUserStorage() UserStorageInterface {
-	return &UserStorage{}
+	return &UserStorage{
+		users: map[string]*storage.User{},
+		names: map[string]string{},
+	}
 }
@@ -1,8 +1,10 @@
 package userstorage_test
 
 import (
+	"context"
 	"httpserver/internal/storage"
 	"httpserver/internal/storage/userstorage"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,20 +13,33 @@ import (
 func TestUserStorage_Add(t *testing.T) {
 	storage := userstorage.NewUserStorage()
 
-	userID := storage.Add("JohnDoe", "password123")
+	userID, err := storage.Add(context.Background(), "JohnDoe", "password123")
 
+	assert.NoError(t, err)
 	assert.NotEmpty(t, userID)
-	user, _ := storage.Get("JohnDoe")
+	user, _ := storage.Get(context.Background(), "JohnDoe")
 	assert.Equal(t, "JohnDoe", user.UserName)
 	assert.Equal(t, "password123", user.Password)
 }
 
+func TestUserStorage_Add_UserNameTaken(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	storageInstance.Add(context.Background(), "JohnDoe", "password123")
+
+	userID, err := storageInstance.Add(context.Background(), "JohnDoe", "password456")
+
+	assert.ErrorIs(t, err, userstorage.ErrUserNameTaken)
+	assert.Empty(t, userID)
+	user, _ := storageInstance.Get(context.Background(), "JohnDoe")
+	assert.Equal(t, "password123", user.Password)
+}
+
 func TestUserStorage_Get(t *testing.T) {
 	storage := userstorage.NewUserStorage()
 
-	storage.Add("JohnDoe", "password123")
+	storage.Add(context.Background(), "JohnDoe", "password123")
 
-	user, err := storage.Get("JohnDoe")
+	user, err := storage.Get(context.Background(), "JohnDoe")
 	assert.NoError(t, err)
 	assert.Equal(t, "JohnDoe", user.UserName)
 	assert.Equal(t, "password123", user.Password)
@@ -33,26 +48,125 @@ func TestUserStorage_Get(t *testing.T) {
 func TestUserStorage_Get_NonExistentUser(t *testing.T) {
 	storageInstance := userstorage.NewUserStorage()
 
-	user, err := storageInstance.Get("NonExistentUser")
+	user, err := storageInstance.Get(context.Background(), "NonExistentUser")
 	assert.Error(t, err)
 	assert.Equal(t, &storage.User{}, user)
 }
 
+func TestUserStorage_GetByID(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	userID, _ := storageInstance.Add(context.Background(), "JohnDoe", "password123")
+
+	user, err := storageInstance.GetByID(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "JohnDoe", user.UserName)
+
+	_, err = storageInstance.GetByID(context.Background(), "unknown")
+	assert.Error(t, err)
+}
+
+func TestUserStorage_Update(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	userID, _ := storageInstance.Add(context.Background(), "JohnDoe", "password123")
+
+	err := storageInstance.Update(context.Background(), &storage.User{UserName: "JohnDoe", Password: "password123", Uuid: userID, Bio: "Hello"})
+	assert.NoError(t, err)
+
+	user, _ := storageInstance.Get(context.Background(), "JohnDoe")
+	assert.Equal(t, "Hello", user.Bio)
+
+	err = storageInstance.Update(context.Background(), &storage.User{UserName: "JaneDoe", Uuid: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestUserStorage_Update_Rename(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	userID, _ := storageInstance.Add(context.Background(), "JohnDoe", "password123")
+	janeID, _ := storageInstance.Add(context.Background(), "JaneDoe", "password123")
+	user, _ := storageInstance.GetByID(context.Background(), userID)
+
+	err := storageInstance.Update(context.Background(), &storage.User{UserName: "JaneDoe", Password: "password123", Uuid: userID})
+	assert.ErrorIs(t, err, userstorage.ErrUserNameTaken)
+
+	err = storageInstance.Update(context.Background(), &storage.User{UserName: "Johnny", Password: "password123", Uuid: userID})
+	assert.NoError(t, err)
+
+	_, err = storageInstance.Get(context.Background(), "JohnDoe")
+	assert.ErrorIs(t, err, userstorage.ErrUserNotFound)
+	renamed, err := storageInstance.Get(context.Background(), "Johnny")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, renamed.Uuid)
+	assert.Equal(t, "JohnDoe", user.UserName, "users returned before keep their values")
+
+	_, err = storageInstance.Add(context.Background(), "JohnDoe", "password123")
+	assert.NoError(t, err, "old alias is released")
+	jane, _ := storageInstance.GetByID(context.Background(), janeID)
+	assert.Equal(t, "JaneDoe", jane.UserName)
+}
+
+func TestUserStorage_ReturnsCopies(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	userID, _ := storageInstance.Add(context.Background(), "JohnDoe", "password123")
+	updated := &storage.User{UserName: "JohnDoe", Password: "password123", Uuid: userID, Roles: []string{"admin"}}
+	storageInstance.Update(context.Background(), updated)
+
+	updated.Roles[0] = "user"
+	user, _ := storageInstance.GetByID(context.Background(), userID)
+	user.Banned = true
+	user.Roles[0] = "user"
+	users, _ := storageInstance.List(context.Background(), 0, 0)
+	users[0].Bio = "changed"
+
+	stored, _ := storageInstance.Get(context.Background(), "JohnDoe")
+	assert.False(t, stored.Banned)
+	assert.Empty(t, stored.Bio)
+	assert.Equal(t, []string{"admin"}, stored.Roles)
+}
+
+func TestUserStorage_Delete(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	userID, _ := storageInstance.Add(context.Background(), "JohnDoe", "password123")
+
+	assert.NoError(t, storageInstance.Delete(context.Background(), userID))
+
+	_, err := storageInstance.Get(context.Background(), "JohnDoe")
+	assert.Error(t, err)
+	assert.Error(t, storageInstance.Delete(context.Background(), userID))
+}
+
+func TestUserStorage_List(t *testing.T) {
+	storageInstance := userstorage.NewUserStorage()
+	for _, name := range []string{"Charlie", "Alice", "Bob"} {
+		storageInstance.Add(context.Background(), name, "password123")
+	}
+
+	users, total := storageInstance.List(context.Background(), 1, 5)
+
+	assert.Equal(t, 3, total)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "Bob", users[0].UserName)
+	assert.Equal(t, "Charlie", users[1].UserName)
+
+	users, total = storageInstance.List(context.Background(), 10, 5)
+	assert.Equal(t, 3, total)
+	assert.Empty(t, users)
+}
+
 func BenchmarkAdd(b *testing.B) {
 	storage := userstorage.NewUserStorage()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		storage.Add("john.doe", "password")
+		storage.Add(context.Background(), "john.doe"+strconv.Itoa(i), "password")
 	}
 }
 
 func BenchmarkGet(b *testing.B) {
 	storage := userstorage.NewUserStorage()
-	storage.Add("john.doe", "password")
+	storage.Add(context.Background(), "john.doe", "password")
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		storage.Get("john.doe")
+		storage.Get(context.Background(), "john.doe")
 	}
 }
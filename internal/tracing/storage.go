@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"context"
+	"httpserver/internal/storage"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/resettokenstorage"
+	"httpserver/internal/storage/sessionstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startStorageSpan(ctx context.Context, storageName string, operation string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, storageName+"."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("storage.name", storageName),
+			attribute.String("storage.operation", operation),
+		),
+	)
+}
+
+func endStorageSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type userStorage struct {
+	next userstorage.UserStorageInterface
+}
+
+func NewUserStorage(next userstorage.UserStorageInterface) userstorage.UserStorageInterface {
+	return &userStorage{next: next}
+}
+
+func (s *userStorage) Add(ctx context.Context, userName string, password string) (string, error) {
+	ctx, span := startStorageSpan(ctx, "user", "add")
+	id, err := s.next.Add(ctx, userName, password)
+	endStorageSpan(span, err)
+
+	return id, err
+}
+
+func (s *userStorage) Get(ctx context.Context, userName string) (*storage.User, error) {
+	ctx, span := startStorageSpan(ctx, "user", "get")
+	user, err := s.next.Get(ctx, userName)
+	endStorageSpan(span, err)
+
+	return user, err
+}
+
+func (s *userStorage) GetByID(ctx context.Context, id string) (*storage.User, error) {
+	ctx, span := startStorageSpan(ctx, "user", "get_by_id")
+	user, err := s.next.GetByID(ctx, id)
+	endStorageSpan(span, err)
+
+	return user, err
+}
+
+func (s *userStorage) Update(ctx context.Context, user *storage.User) error {
+	ctx, span := startStorageSpan(ctx, "user", "update")
+	err := s.next.Update(ctx, user)
+	endStorageSpan(span, err)
+
+	return err
+}
+
+func (s *userStorage) Delete(ctx context.Context, id string) error {
+	ctx, span := startStorageSpan(ctx, "user", "delete")
+	err := s.next.Delete(ctx, id)
+	endStorageSpan(span, err)
+
+	return err
+}
+
+func (s *userStorage) List(ctx context.Context, offset int, limit int) ([]*storage.User, int) {
+	ctx, span := startStorageSpan(ctx, "user", "list")
+	defer endStorageSpan(span, nil)
+
+	return s.next.List(ctx, offset, limit)
+}
+
+type tokenStorage struct {
+	next tokenstorage.TokenStorageInterface
+}
+
+func NewTokenStorage(next tokenstorage.TokenStorageInterface) tokenstorage.TokenStorageInterface {
+	return &tokenStorage{next: next}
+}
+
+func (s *tokenStorage) Add(ctx context.Context, token string, userID string) {
+	ctx, span := startStorageSpan(ctx, "token", "add")
+	defer endStorageSpan(span, nil)
+
+	s.next.Add(ctx, token, userID)
+}
+
+func (s *tokenStorage) Get(ctx context.Context, token string) (string, error) {
+	ctx, span := startStorageSpan(ctx, "token", "get")
+	userID, err := s.next.Get(ctx, token)
+	endStorageSpan(span, err)
+
+	return userID, err
+}
+
+func (s *tokenStorage) Delete(ctx context.Context, token string) {
+	ctx, span := startStorageSpan(ctx, "token", "delete")
+	defer endStorageSpan(span, nil)
+
+	s.next.Delete(ctx, token)
+}
+
+func (s *tokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	ctx, span := startStorageSpan(ctx, "token", "delete_by_user")
+	defer endStorageSpan(span, nil)
+
+	s.next.DeleteByUser(ctx, userID)
+}
+
+type activeUsersStorage struct {
+	next activeuserstorage.ActiveUsersStorageInterface
+}
+
+func NewActiveUsersStorage(next activeuserstorage.ActiveUsersStorageInterface) activeuserstorage.ActiveUsersStorageInterface {
+	return &activeUsersStorage{next: next}
+}
+
+func (s *activeUsersStorage) Add(ctx context.Context, userID string) {
+	ctx, span := startStorageSpan(ctx, "active_user", "add")
+	defer endStorageSpan(span, nil)
+
+	s.next.Add(ctx, userID)
+}
+
+func (s *activeUsersStorage) Get(ctx context.Context, id string) (*storage.User, error) {
+	ctx, span := startStorageSpan(ctx, "active_user", "get")
+	user, err := s.next.Get(ctx, id)
+	endStorageSpan(span, err)
+
+	return user, err
+}
+
+func (s *activeUsersStorage) Delete(ctx context.Context, userID string) {
+	ctx, span := startStorageSpan(ctx, "active_user", "delete")
+	defer endStorageSpan(span, nil)
+
+	s.next.Delete(ctx, userID)
+}
+
+func (s *activeUsersStorage) GetNames(ctx context.Context) []string {
+	ctx, span := startStorageSpan(ctx, "active_user", "get_names")
+	defer endStorageSpan(span, nil)
+
+	return s.next.GetNames(ctx)
+}
+
+func (s *activeUsersStorage) List(ctx context.Context, query activeuserstorage.Query) ([]activeuserstorage.ActiveUser, int) {
+	ctx, span := startStorageSpan(ctx, "active_user", "list")
+	defer endStorageSpan(span, nil)
+
+	return s.next.List(ctx, query)
+}
+
+type sessionStorage struct {
+	name string
+	next sessionstorage.SessionStorageInterface
+}
+
+func NewSessionStorage(next sessionstorage.SessionStorageInterface) sessionstorage.SessionStorageInterface {
+	return &sessionStorage{name: "session", next: next}
+}
+
+// NewStreamTokenStorage wraps the storage of the stream tokens, it is a session storage reported under its own name.
+func NewStreamTokenStorage(next sessionstorage.SessionStorageInterface) sessionstorage.SessionStorageInterface {
+	return &sessionStorage{name: "stream_token", next: next}
+}
+
+func (s *sessionStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	ctx, span := startStorageSpan(ctx, s.name, "add")
+	defer endStorageSpan(span, nil)
+
+	s.next.Add(ctx, token, userID, expiresAt)
+}
+
+func (s *sessionStorage) Get(ctx context.Context, token string) (string, error) {
+	ctx, span := startStorageSpan(ctx, s.name, "get")
+	userID, err := s.next.Get(ctx, token)
+	endStorageSpan(span, err)
+
+	return userID, err
+}
+
+func (s *sessionStorage) Delete(ctx context.Context, token string) {
+	ctx, span := startStorageSpan(ctx, s.name, "delete")
+	defer endStorageSpan(span, nil)
+
+	s.next.Delete(ctx, token)
+}
+
+func (s *sessionStorage) DeleteByUser(ctx context.Context, userID string) {
+	ctx, span := startStorageSpan(ctx, s.name, "delete_by_user")
+	defer endStorageSpan(span, nil)
+
+	s.next.DeleteByUser(ctx, userID)
+}
+
+type resetTokenStorage struct {
+	next resettokenstorage.ResetTokenStorageInterface
+}
+
+func NewResetTokenStorage(next resettokenstorage.ResetTokenStorageInterface) resettokenstorage.ResetTokenStorageInterface {
+	return &resetTokenStorage{next: next}
+}
+
+func (s *resetTokenStorage) Add(ctx context.Context, token string, userID string, expiresAt time.Time) {
+	ctx, span := startStorageSpan(ctx, "reset_token", "add")
+	defer endStorageSpan(span, nil)
+
+	s.next.Add(ctx, token, userID, expiresAt)
+}
+
+func (s *resetTokenStorage) Get(ctx context.Context, token string) (string, error) {
+	ctx, span := startStorageSpan(ctx, "reset_token", "get")
+	userID, err := s.next.Get(ctx, token)
+	endStorageSpan(span, err)
+
+	return userID, err
+}
+
+func (s *resetTokenStorage) DeleteByUser(ctx context.Context, userID string) {
+	ctx, span := startStorageSpan(ctx, "reset_token", "delete_by_user")
+	defer endStorageSpan(span, nil)
+
+	s.next.DeleteByUser(ctx, userID)
+}
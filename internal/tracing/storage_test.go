@@ -0,0 +1,64 @@
+package tracing_test
+
+import (
+	"context"
+	"httpserver/internal/storage/activeuserstorage"
+	"httpserver/internal/storage/tokenstorage"
+	"httpserver/internal/storage/userstorage"
+	"httpserver/internal/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestUserStorage_CreatesChildSpans(t *testing.T) {
+	exporter := setupExporter(t)
+	userStorage := tracing.NewUserStorage(userstorage.NewUserStorage())
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "parent")
+	userStorage.Add(ctx, "JohnDoe", "password123")
+	_, err := userStorage.Get(ctx, "JaneDoe")
+	parent.End()
+
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "user.add", spans[0].Name)
+	assert.Equal(t, "user.get", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent.SpanID())
+}
+
+func TestTokenStorage_CreatesSpans(t *testing.T) {
+	exporter := setupExporter(t)
+	tokenStorage := tracing.NewTokenStorage(tokenstorage.NewTokenStorage())
+
+	tokenStorage.Add(context.Background(), "abc123", "1")
+	result, err := tokenStorage.Get(context.Background(), "abc123")
+	tokenStorage.Delete(context.Background(), "abc123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", result)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "token.delete", spans[2].Name)
+}
+
+func TestActiveUsersStorage_CreatesSpans(t *testing.T) {
+	exporter := setupExporter(t)
+	users := userstorage.NewUserStorage()
+	activeUsersStorage := tracing.NewActiveUsersStorage(activeuserstorage.NewActiveUsersStorage(users))
+	id, _ := users.Add(context.Background(), "JohnDoe", "password123")
+
+	activeUsersStorage.Add(context.Background(), id)
+	assert.Equal(t, []string{"JohnDoe"}, activeUsersStorage.GetNames(context.Background()))
+	activeUsersStorage.Delete(context.Background(), id)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "active_user.get_names", spans[1].Name)
+}
@@ -0,0 +1,78 @@
+package tracing_test
+
+import (
+	"context"
+	"httpserver/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func TestMiddleware_CreatesSpanPerRequest(t *testing.T) {
+	exporter := setupExporter(t)
+
+	router := chi.NewRouter()
+	router.Use(tracing.Middleware)
+	router.Get("/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Tracer().Start(r.Context(), "child")
+		span.End()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "HTTP GET /user/{id}", server.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+}
+
+func TestMiddleware_InjectsTraceContextIntoResponse(t *testing.T) {
+	setupExporter(t)
+
+	router := chi.NewRouter()
+	router.Use(tracing.Middleware)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.NotEmpty(t, w.Header().Get("traceparent"))
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), "zipkin", "")
+
+	assert.Error(t, err)
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.ExporterNone, "")
+
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
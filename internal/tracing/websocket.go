@@ -0,0 +1,15 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func StartEvent(ctx context.Context, event string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "ws.event "+event,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("ws.event", event)),
+	)
+}
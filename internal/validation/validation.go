@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Errors []FieldError
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+type Rule struct {
+	Name  string
+	Param string
+}
+
+const (
+	RuleRequired = "required"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleCharset  = "charset"
+	RuleOneOf    = "oneof"
+	RuleUnknown  = "unknown"
+	RuleUrl      = "url"
+)
+
+var (
+	charsetsMu sync.RWMutex
+	charsets   = map[string]func(rune) bool{
+		"alpha": unicode.IsLetter,
+		"alnum": func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		},
+		"printable": unicode.IsPrint,
+	}
+)
+
+func RegisterCharset(name string, allowed func(rune) bool) {
+	charsetsMu.Lock()
+	defer charsetsMu.Unlock()
+
+	charsets[name] = allowed
+}
+
+func ParseTag(tag string) []Rule {
+	if tag == "" {
+		return nil
+	}
+
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, Rule{Name: strings.TrimSpace(name), Param: strings.TrimSpace(param)})
+	}
+
+	return rules
+}
+
+func FieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+func Validate(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		label := field.Tag.Get("label")
+		if label == "" {
+			label = FieldName(field)
+		}
+
+		for _, rule := range ParseTag(field.Tag.Get("validate")) {
+			if message := check(rule, value.Field(i), label); message != "" {
+				errs = append(errs, FieldError{Field: FieldName(field), Rule: rule.Name, Message: message})
+				if rule.Name == RuleRequired {
+					break
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func check(rule Rule, value reflect.Value, label string) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if rule.Name == RuleRequired {
+				return label + " is required"
+			}
+			return ""
+		}
+		if rule.Name == RuleRequired {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch rule.Name {
+	case RuleRequired:
+		if value.IsZero() {
+			return label + " is required"
+		}
+	case RuleMin:
+		if limit, _ := strconv.Atoi(rule.Param); size(value) < limit {
+			if unit(value) == "" {
+				return fmt.Sprintf("%s should be at least %d", label, limit)
+			}
+			return fmt.Sprintf("%s should be %d %s or longer", label, limit, unit(value))
+		}
+	case RuleMax:
+		if limit, _ := strconv.Atoi(rule.Param); size(value) > limit {
+			if unit(value) == "" {
+				return fmt.Sprintf("%s should be at most %d", label, limit)
+			}
+			return fmt.Sprintf("%s should be %d %s or shorter", label, limit, unit(value))
+		}
+	case RuleCharset:
+		charsetsMu.RLock()
+		allowed, ok := charsets[rule.Param]
+		charsetsMu.RUnlock()
+		if !ok {
+			return fmt.Sprintf("%s uses unknown charset %s", label, rule.Param)
+		}
+		if value.Kind() == reflect.String && strings.IndexFunc(value.String(), func(r rune) bool { return !allowed(r) }) >= 0 {
+			return fmt.Sprintf("%s contains characters outside of %s", label, rule.Param)
+		}
+	case RuleUrl:
+		if value.Kind() == reflect.String && !value.IsZero() && !isHttpUrl(value.String()) {
+			return label + " should be an absolute http(s) URL"
+		}
+	case RuleOneOf:
+		options := strings.Split(rule.Param, "|")
+		if value.Kind() == reflect.String && !value.IsZero() && !contains(options, value.String()) {
+			return fmt.Sprintf("%s should be one of %s", label, strings.Join(options, ", "))
+		}
+	}
+
+	return ""
+}
+
+func size(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(value.Uint())
+	}
+
+	return 0
+}
+
+func unit(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return "chars"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return "items"
+	}
+
+	return ""
+}
+
+func contains(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isHttpUrl(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,92 @@
+package validation_test
+
+import (
+	"httpserver/internal/validation"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type signup struct {
+	UserName string   `json:"userName" label:"username" validate:"required,min=4,max=8,charset=alnum"`
+	Role     string   `json:"role,omitempty" validate:"oneof=admin|member"`
+	Age      int      `json:"age" validate:"min=18"`
+	Tags     []string `json:"tags" validate:"max=2"`
+}
+
+func TestValidate_Valid(t *testing.T) {
+	err := validation.Validate(&signup{UserName: "JohnDoe", Role: "admin", Age: 30})
+
+	assert.NoError(t, err)
+}
+
+func TestValidate_CountsRunesNotBytes(t *testing.T) {
+	err := validation.Validate(signup{UserName: "Jöhn", Age: 18})
+
+	assert.NoError(t, err)
+}
+
+func TestValidate_AggregatesErrors(t *testing.T) {
+	err := validation.Validate(&signup{UserName: "J!", Role: "owner", Age: 3, Tags: []string{"a", "b", "c"}})
+
+	assert.Equal(t, validation.Errors{
+		{Field: "userName", Rule: "min", Message: "username should be 4 chars or longer"},
+		{Field: "userName", Rule: "charset", Message: "username contains characters outside of alnum"},
+		{Field: "role", Rule: "oneof", Message: "role should be one of admin, member"},
+		{Field: "age", Rule: "min", Message: "age should be at least 18"},
+		{Field: "tags", Rule: "max", Message: "tags should be 2 items or shorter"},
+	}, err)
+}
+
+func TestValidate_RequiredStopsFieldRules(t *testing.T) {
+	err := validation.Validate(&signup{Age: 18})
+
+	assert.Equal(t, validation.Errors{
+		{Field: "userName", Rule: "required", Message: "username is required"},
+	}, err)
+	assert.Equal(t, "username is required", err.Error())
+}
+
+func TestRegisterCharset(t *testing.T) {
+	validation.RegisterCharset("digits", unicode.IsDigit)
+
+	type pin struct {
+		Code string `json:"code" validate:"charset=digits"`
+	}
+
+	assert.NoError(t, validation.Validate(pin{Code: "1234"}))
+	assert.Error(t, validation.Validate(pin{Code: "12a4"}))
+}
+
+func TestParseTag(t *testing.T) {
+	rules := validation.ParseTag("required, min=4,charset=alnum")
+
+	assert.Equal(t, []validation.Rule{
+		{Name: "required"},
+		{Name: "min", Param: "4"},
+		{Name: "charset", Param: "alnum"},
+	}, rules)
+	assert.Nil(t, validation.ParseTag(""))
+}
+
+type profileUpdate struct {
+	DisplayName *string `json:"displayName" validate:"max=4"`
+	AvatarUrl   *string `json:"avatarUrl" validate:"url"`
+	Bio         *string `json:"bio" validate:"required"`
+}
+
+func TestValidate_Pointers(t *testing.T) {
+	name, avatar, bio := "Johnny", "ftp://example.com/a.png", ""
+
+	err := validation.Validate(&profileUpdate{DisplayName: &name, AvatarUrl: &avatar})
+
+	assert.Equal(t, validation.Errors{
+		{Field: "displayName", Rule: "max", Message: "displayName should be 4 chars or shorter"},
+		{Field: "avatarUrl", Rule: "url", Message: "avatarUrl should be an absolute http(s) URL"},
+		{Field: "bio", Rule: "required", Message: "bio is required"},
+	}, err)
+
+	name, avatar = "John", "https://example.com/a.png"
+	assert.NoError(t, validation.Validate(&profileUpdate{DisplayName: &name, AvatarUrl: &avatar, Bio: &bio}))
+}